@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// AuditRecord is a line of the audit log. Hash is the sha256 of the record
+// without Hash, which contains the Hash of the previous record as PrevHash.
+type AuditRecord struct {
+	Time        time.Time  `json:"time"`
+	Host        string     `json:"host"`
+	User        string     `json:"user"`
+	RunnerID    int        `json:"runner_id"`
+	Description string     `json:"description"`
+	Type        string     `json:"type"`
+	Groups      []string   `json:"groups"`
+	Projects    []string   `json:"projects"`
+	Tags        []string   `json:"tags"`
+	ContactedAt *time.Time `json:"contacted_at"`
+	Outcome     string     `json:"outcome"`
+	StatusCode  int        `json:"status_code,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	PrevHash    string     `json:"prev_hash"`
+	Hash        string     `json:"hash"`
+}
+
+// AuditLog appends a JSON Lines record for each runner to a file. It is safe
+// for concurrent use.
+type AuditLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	host     string
+	user     string
+	lastHash string
+}
+
+// OpenAuditLog opens or creates the audit log at path. The hash chain of an
+// existing log is verified first.
+func OpenAuditLog(path, host, user string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	lastHash, err := VerifyAuditLog(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s: %w", path, err)
+	}
+	return &AuditLog{file: file, host: host, user: user, lastHash: lastHash}, nil
+}
+
+// Write appends a record of the runner and its result.
+func (a *AuditLog) Write(rner *gitlab.RunnerDetails, result DeletionResult) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	record := AuditRecord{
+		Time:        time.Now().UTC(),
+		Host:        a.host,
+		User:        a.user,
+		RunnerID:    rner.ID,
+		Description: rner.Description,
+		Type:        rner.RunnerType,
+		Groups:      groupPaths(rner),
+		Projects:    projectPaths(rner),
+		Tags:        rner.TagList,
+		ContactedAt: rner.ContactedAt,
+		Outcome:     result.Outcome,
+		StatusCode:  result.StatusCode,
+		Reason:      result.Reason,
+		Error:       result.Error,
+		PrevHash:    a.lastHash,
+	}
+	hash, err := record.hash()
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	a.lastHash = hash
+	return nil
+}
+
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
+
+// VerifyAuditLog checks the hash chain of all records and returns the hash of
+// the last record.
+func VerifyAuditLog(r io.Reader) (string, error) {
+	lastHash := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		record := AuditRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return "", fmt.Errorf("line %d: %w", line, err)
+		}
+		if record.PrevHash != lastHash {
+			return "", fmt.Errorf("line %d: previous hash doesn't match, records were removed or reordered", line)
+		}
+		hash, err := record.hash()
+		if err != nil {
+			return "", err
+		}
+		if hash != record.Hash {
+			return "", fmt.Errorf("line %d: hash doesn't match, record was modified", line)
+		}
+		lastHash = hash
+	}
+	return lastHash, scanner.Err()
+}
+
+func (r AuditRecord) hash() (string, error) {
+	r.Hash = ""
+	content, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
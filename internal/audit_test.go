@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	contactedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rner := runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform", "platform/infra")
+	rner.Description = "first"
+	rner.RunnerType = "group_type"
+	rner.TagList = []string{"docker"}
+	rner.ContactedAt = &contactedAt
+
+	auditLog, err := OpenAuditLog(path, "https://gitlab.com", "jane")
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Write(rner, DeletionResult{ID: 1, Outcome: OutcomeDeleted, StatusCode: 204}))
+	require.NoError(t, auditLog.Close())
+
+	// Records are appended to an existing log
+	auditLog, err = OpenAuditLog(path, "https://gitlab.com", "jane")
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Write(&gitlab.RunnerDetails{ID: 2}, DeletionResult{ID: 2, Outcome: OutcomeFailed, Error: "403 Forbidden"}))
+	require.NoError(t, auditLog.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	first := AuditRecord{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "https://gitlab.com", first.Host)
+	assert.Equal(t, "jane", first.User)
+	assert.Equal(t, 1, first.RunnerID)
+	assert.Equal(t, "first", first.Description)
+	assert.Equal(t, "group_type", first.Type)
+	assert.Equal(t, []string{"platform"}, first.Groups)
+	assert.Equal(t, []string{"platform/infra"}, first.Projects)
+	assert.Equal(t, []string{"docker"}, first.Tags)
+	assert.Equal(t, contactedAt, *first.ContactedAt)
+	assert.Equal(t, OutcomeDeleted, first.Outcome)
+	assert.Empty(t, first.PrevHash)
+
+	second := AuditRecord{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, "403 Forbidden", second.Error)
+
+	lastHash, err := VerifyAuditLog(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, second.Hash, lastHash)
+
+	t.Run("Modified record", func(t *testing.T) {
+		modified := strings.Replace(string(content), `"user":"jane"`, `"user":"john"`, 1)
+		_, err := VerifyAuditLog(strings.NewReader(modified))
+		assert.EqualError(t, err, "line 1: hash doesn't match, record was modified")
+	})
+
+	t.Run("Removed record", func(t *testing.T) {
+		_, err := VerifyAuditLog(strings.NewReader(lines[1]))
+		assert.EqualError(t, err, "line 1: previous hash doesn't match, records were removed or reordered")
+	})
+
+	t.Run("Tampered log is not opened", func(t *testing.T) {
+		tampered := filepath.Join(t.TempDir(), "audit.jsonl")
+		require.NoError(t, os.WriteFile(tampered, []byte(lines[1]+"\n"), 0600))
+		_, err := OpenAuditLog(tampered, "https://gitlab.com", "jane")
+		assert.ErrorContains(t, err, "previous hash doesn't match")
+	})
+}
+
+func TestCleanupRunnersAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := OpenAuditLog(path, "https://gitlab.com", "jane")
+	require.NoError(t, err)
+
+	logger, _ := logrusTest.NewNullLogger()
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Online: true}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 2).Return(&gitlab.Response{Response: &http.Response{StatusCode: 204}}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger, AuditLog: auditLog}
+	_, err = clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}})
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Close())
+	mock.AssertExpectations(t)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"runner_id":1`)
+	assert.Contains(t, lines[0], `"outcome":"skipped"`)
+	assert.Contains(t, lines[1], `"runner_id":2`)
+	assert.Contains(t, lines[1], `"outcome":"deleted"`)
+	_, err = VerifyAuditLog(strings.NewReader(string(content)))
+	assert.NoError(t, err)
+
+	t.Run("Apply plan", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		auditLog, err := OpenAuditLog(path, "https://gitlab.com", "jane")
+		require.NoError(t, err)
+		plan, err := NewPlan("https://gitlab.com", 2, []*gitlab.RunnerDetails{{ID: 1, Description: "first"}, {ID: 2}})
+		require.NoError(t, err)
+
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Online: true}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2}, &gitlab.Response{}, nil)
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 2).Return(&gitlab.Response{}, nil).Once()
+
+		clinar := Clinar{Client: mock, Logger: logger, AuditLog: auditLog}
+		_, err = clinar.ApplyPlan(context.Background(), plan)
+		require.NoError(t, err)
+		require.NoError(t, auditLog.Close())
+		mock.AssertExpectations(t)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"runner_id":1,"description":"first"`)
+		assert.Contains(t, string(content), `"reason":"runner came back online since the plan was created"`)
+		assert.Contains(t, string(content), `"runner_id":2`)
+	})
+}
+
+func TestCleanupRunnersAuditLogPerDeletion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := OpenAuditLog(path, "https://gitlab.com", "jane")
+	require.NoError(t, err)
+
+	logger, _ := logrusTest.NewNullLogger()
+	mock := &mocks.GitLabClient{}
+	mockGetRunnerDetails(mock, 2)
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(&gitlab.Response{}, nil).Once()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 2).Run(func(_ context.Context, rid int, options ...gitlab.RequestOptionFunc) {
+		// The first batch is already audited while the second one is running
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"runner_id":1`)
+	}).Return(&gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger, AuditLog: auditLog, BatchSize: 1}
+	_, err = clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}})
+	require.NoError(t, err)
+	require.NoError(t, auditLog.Close())
+	mock.AssertExpectations(t)
+}
+
+func TestAuditLogConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := OpenAuditLog(path, "https://gitlab.com", "jane")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			assert.NoError(t, auditLog.Write(&gitlab.RunnerDetails{ID: id}, DeletionResult{ID: id, Outcome: OutcomeDeleted}))
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, auditLog.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 20)
+	_, err = VerifyAuditLog(bytes.NewReader(content))
+	assert.NoError(t, err)
+}
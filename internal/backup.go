@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Backup is an archive of the full details of deleted runners.
+type Backup struct {
+	Host      string                  `json:"host"`
+	CreatedAt time.Time               `json:"created_at"`
+	Runners   []*gitlab.RunnerDetails `json:"runners"`
+}
+
+// WriteBackup writes the details of the runners without tokens into a
+// timestamped archive in dir and returns the path of the archive.
+func WriteBackup(dir, host string, runners []*gitlab.RunnerDetails) (string, error) {
+	backup := Backup{Host: host, CreatedAt: time.Now().UTC(), Runners: withoutTokens(runners)}
+	content, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("clinar-backup-%s.json", backup.CreatedAt.Format("20060102T150405.000Z")))
+	if err := os.WriteFile(path, append(content, '\n'), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ReadBackup reads an archive written by WriteBackup.
+func ReadBackup(r io.Reader) (*Backup, error) {
+	backup := &Backup{}
+	if err := json.NewDecoder(r).Decode(backup); err != nil {
+		return nil, fmt.Errorf("invalid backup: %w", err)
+	}
+	return backup, nil
+}
+
+// WriteRestoreInfo writes a gitlab-runner register command with the settings
+// of each runner of the backup. Groups and projects can't be set on register
+// and are written as comments.
+func WriteRestoreInfo(w io.Writer, backup *Backup) error {
+	for i, rner := range backup.Runners {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %d - %s (%s)\n", rner.ID, rner.Description, rner.RunnerType)
+		if groups := groupPaths(rner); len(groups) > 0 {
+			fmt.Fprintf(w, "# Groups: %s\n", strings.Join(groups, ", "))
+		}
+		if projects := projectPaths(rner); len(projects) > 0 {
+			fmt.Fprintf(w, "# Projects: %s\n", strings.Join(projects, ", "))
+		}
+		if rner.Version != "" {
+			fmt.Fprintf(w, "# Version: %s (%s/%s)\n", rner.Version, rner.Platform, rner.Architecture)
+		}
+
+		args := []string{
+			"--url " + shellQuote(backup.Host),
+			"--registration-token " + shellQuote("<registration token>"),
+			"--description " + shellQuote(rner.Description),
+		}
+		if len(rner.TagList) > 0 {
+			args = append(args, "--tag-list "+shellQuote(strings.Join(rner.TagList, ",")))
+		}
+		args = append(args,
+			fmt.Sprintf("--run-untagged=%t", rner.RunUntagged),
+			fmt.Sprintf("--locked=%t", rner.Locked),
+		)
+		if rner.AccessLevel != "" {
+			args = append(args, "--access-level "+shellQuote(rner.AccessLevel))
+		}
+		if rner.MaximumTimeout > 0 {
+			args = append(args, fmt.Sprintf("--maximum-timeout %d", rner.MaximumTimeout))
+		}
+		if rner.MaintenanceNote != "" {
+			args = append(args, "--maintenance-note "+shellQuote(rner.MaintenanceNote))
+		}
+		args = append(args, fmt.Sprintf("--paused=%t", rner.Paused))
+
+		if _, err := fmt.Fprintf(w, "gitlab-runner register --non-interactive \\\n  %s\n", strings.Join(args, " \\\n  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
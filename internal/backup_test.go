@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	rner := runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform", "platform/infra")
+	rner.Description = "docker runner"
+	rner.RunnerType = "project_type"
+	rner.Token = "secret"
+	rner.TagList = []string{"docker", "gpu"}
+	rner.RunUntagged = true
+	rner.AccessLevel = "ref_protected"
+	rner.MaximumTimeout = 3600
+	rner.MaintenanceNote = "owned by team's infra"
+	rner.Version = "16.1.0"
+	rner.Platform = "linux"
+	rner.Architecture = "amd64"
+
+	path, err := WriteBackup(dir, "https://gitlab.com", []*gitlab.RunnerDetails{rner, {ID: 2, Description: "minimal", RunnerType: "instance_type"}})
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.Regexp(t, `^clinar-backup-\d{8}T\d{6}\.\d{3}Z\.json$`, filepath.Base(path))
+	assert.Equal(t, "secret", rner.Token)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	backup, err := ReadBackup(file)
+	require.NoError(t, err)
+	assert.Equal(t, "https://gitlab.com", backup.Host)
+	require.Len(t, backup.Runners, 2)
+	assert.Empty(t, backup.Runners[0].Token)
+	assert.Equal(t, 3600, backup.Runners[0].MaximumTimeout)
+	assert.Equal(t, "platform/infra", backup.Runners[0].Projects[0].PathWithNamespace)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteRestoreInfo(out, backup))
+	assert.Equal(t, `# 1 - docker runner (project_type)
+# Groups: platform
+# Projects: platform/infra
+# Version: 16.1.0 (linux/amd64)
+gitlab-runner register --non-interactive \
+  --url 'https://gitlab.com' \
+  --registration-token '<registration token>' \
+  --description 'docker runner' \
+  --tag-list 'docker,gpu' \
+  --run-untagged=true \
+  --locked=false \
+  --access-level 'ref_protected' \
+  --maximum-timeout 3600 \
+  --maintenance-note 'owned by team'\''s infra' \
+  --paused=false
+
+# 2 - minimal (instance_type)
+gitlab-runner register --non-interactive \
+  --url 'https://gitlab.com' \
+  --registration-token '<registration token>' \
+  --description 'minimal' \
+  --run-untagged=false \
+  --locked=false \
+  --paused=false
+`, out.String())
+
+	t.Run("Invalid backup", func(t *testing.T) {
+		_, err := ReadBackup(bytes.NewBufferString("no json"))
+		assert.ErrorContains(t, err, "invalid backup")
+	})
+}
+
+func TestCleanupRunnersBackup(t *testing.T) {
+	t.Run("Backup before delete", func(t *testing.T) {
+		dir := t.TempDir()
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Online: true}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 2).Return(&gitlab.Response{}, nil).Once()
+
+		clinar := Clinar{Client: mock, Logger: logger, BackupDir: dir, Host: "https://gitlab.com"}
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2, Description: "second"}})
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+
+		files, err := filepath.Glob(filepath.Join(dir, "clinar-backup-*.json"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		file, err := os.Open(files[0])
+		require.NoError(t, err)
+		defer file.Close()
+		backup, err := ReadBackup(file)
+		require.NoError(t, err)
+		// All runners are backed up before the first one is re-checked and deleted
+		require.Len(t, backup.Runners, 2)
+		assert.Equal(t, "second", backup.Runners[1].Description)
+	})
+
+	t.Run("Nothing deleted if backup fails", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+
+		clinar := Clinar{Client: mock, Logger: logger, BackupDir: filepath.Join(t.TempDir(), "missing")}
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}})
+		assert.ErrorContains(t, err, "writing backup, no runners deleted")
+		mock.AssertExpectations(t)
+	})
+}
@@ -1,136 +1,582 @@
 package internal
 
 import (
+	"context"
+	"fmt"
 	"regexp"
-	"strconv"
 	"sync"
+	"time"
 
+	"github.com/blang/semver"
+	"github.com/google/cel-go/cel"
 	"github.com/sirupsen/logrus"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/time/rate"
 )
 
-const runnerState = "offline"
+const (
+	defaultRunnerState = "offline"
+	pausedRunnerState  = "paused"
+)
 
+// GitLabClient contains the GitLab API calls used by Clinar. The context of a
+// call is passed explicitly so that wrappers like RetryClient can use it.
 type GitLabClient interface {
-	GetRunnerDetails(rid interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error)
-	ListRunners(opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error)
-	DeleteRegisteredRunnerByID(rid int, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	GetRunnerDetails(ctx context.Context, rid interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error)
+	ListRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error)
+	ListAllRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error)
+	ListGroupsRunners(ctx context.Context, gid interface{}, opt *gitlab.ListGroupsRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error)
+	ListProjectRunners(ctx context.Context, pid interface{}, opt *gitlab.ListProjectRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error)
+	ListDescendantGroups(ctx context.Context, gid interface{}, opt *gitlab.ListDescendantGroupsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error)
+	CurrentUser(ctx context.Context, options ...gitlab.RequestOptionFunc) (*gitlab.User, *gitlab.Response, error)
+	DeleteRegisteredRunnerByID(ctx context.Context, rid int, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error)
+	UpdateRunnerDetails(ctx context.Context, rid interface{}, opt *gitlab.UpdateRunnerDetailsOptions, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error)
 }
 
 type Clinar struct {
-	Client         GitLabClient
-	Logger         *logrus.Logger
-	ExcludeFilter  []string       `mapstructure:"exclude"`
-	IncludePattern *regexp.Regexp `mapstructure:"include"`
+	Client             GitLabClient
+	Logger             *logrus.Logger
+	ExcludeFilter      []string        `mapstructure:"exclude"`
+	IncludePattern     *regexp.Regexp  `mapstructure:"include"`
+	States             []string        `mapstructure:"status"`
+	Types              []string        `mapstructure:"type"`
+	AllRunners         bool            `mapstructure:"all"`
+	Groups             []string        `mapstructure:"group"`
+	Projects           []string        `mapstructure:"project"`
+	IncludeSubgroups   bool            `mapstructure:"include-subgroups"`
+	IncludeTags        []string        `mapstructure:"include-tag"`
+	ExcludeTags        []string        `mapstructure:"exclude-tag"`
+	TagMatch           string          `mapstructure:"tag-match"`
+	ProtectTags        []string        `mapstructure:"protect-tag"`
+	MinVersion         *semver.Version `mapstructure:"min-version"`
+	MaxVersion         *semver.Version `mapstructure:"max-version"`
+	Platforms          []string        `mapstructure:"platform"`
+	Architectures      []string        `mapstructure:"architecture"`
+	OlderThan          time.Duration   `mapstructure:"older-than"`
+	SkipNeverContacted bool            `mapstructure:"skip-never-contacted"`
+	Where              cel.Program     `mapstructure:"where"`
+	GracePeriod        time.Duration   `mapstructure:"grace-period"`
+	MaxDelete          int             `mapstructure:"max-delete"`
+	MaxDeletePercent   float64         `mapstructure:"max-delete-percent"`
+	Force              bool            `mapstructure:"force"`
+	// Concurrency is the number of runner details which are fetched in parallel.
+	Concurrency int `mapstructure:"concurrency"`
+	// DeleteParallelism is the number of runners which are deleted in parallel.
+	DeleteParallelism int `mapstructure:"delete-parallelism"`
+	// DeleteRate limits the delete requests per second if greater than 0.
+	DeleteRate float64 `mapstructure:"delete-rate"`
+	// BatchSize is the number of runners which are deleted before pausing for
+	// BatchPause. If it is 0 all runners are deleted in one batch.
+	BatchSize  int           `mapstructure:"batch-size"`
+	BatchPause time.Duration `mapstructure:"batch-pause"`
+	// BackupDir is the directory the details of runners are written to before
+	// they are deleted. No backup is written if it is empty.
+	BackupDir string `mapstructure:"backup-dir"`
+	// Host is the GitLab host written into backups.
+	Host string
+	// AuditLog gets a record for every runner CleanupRunners acts on if set.
+	AuditLog *AuditLog
+	// TotalRunners is the number of runners returned by GetAllRunners. It is
+	// used to check MaxDeletePercent.
+	TotalRunners int
+	// scopeGroups are the Groups including their descendants if IncludeSubgroups
+	// is set. They are resolved by scopedGroups.
+	scopeGroups []string
 }
 
-// GetRunnerDetails return the gitlab.RunnerDetails for all given []*gitlab.Runner
-func (c *Clinar) GetRunnerDetails(rners []*gitlab.Runner) []*gitlab.RunnerDetails {
+// GetRunnerDetails return the gitlab.RunnerDetails for all given []*gitlab.Runner.
+// The details are fetched by Concurrency workers. The order of the runners is kept.
+// If ctx is done no further details are fetched and the error of ctx is returned.
+func (c *Clinar) GetRunnerDetails(ctx context.Context, rners []*gitlab.Runner) ([]*gitlab.RunnerDetails, error) {
+	results := make([]detailsResultWrapper, len(rners))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(c.concurrency(), len(rners)); i++ {
+		wg.Add(1)
+		go c.getRunnerDetailsWorker(ctx, rners, jobs, results, &wg)
+	}
+	for i := range rners {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	runnerDetails := []*gitlab.RunnerDetails{}
-	// TODO: We could get Details in Chunks with goroutines
-	for _, rner := range rners {
-		details, _, err := c.Client.GetRunnerDetails(rner.ID)
-		if err != nil {
-			c.Logger.Errorf("Error %s getting runner details for runner ID %d", err, rner.ID)
-		} else {
-			grpsNprojs := []abstractRunnerLocation{}
-			for _, grp := range details.Groups {
-				grpsNprojs = append(grpsNprojs, abstractRunnerLocation{grp.ID, grp.Name})
-			}
-			for _, proj := range details.Projects {
-				grpsNprojs = append(grpsNprojs, abstractRunnerLocation{proj.ID, proj.Name})
+	for i, result := range results {
+		if result.err != nil {
+			c.Logger.Errorf("Error %s getting runner details for runner ID %d", result.err, rners[i].ID)
+		} else if c.isSelected(result.details) {
+			runnerDetails = append(runnerDetails, result.details)
+		}
+	}
+	return runnerDetails, nil
+}
+
+func (c *Clinar) getRunnerDetailsWorker(ctx context.Context, rners []*gitlab.Runner, jobs <-chan int, results []detailsResultWrapper, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for i := range jobs {
+		if ctx.Err() != nil {
+			continue
+		}
+		details, _, err := c.Client.GetRunnerDetails(ctx, rners[i].ID)
+		results[i] = detailsResultWrapper{details, err}
+	}
+}
+
+func (c Clinar) concurrency() int {
+	if c.Concurrency < 1 {
+		return 1
+	}
+	return c.Concurrency
+}
+
+func (c Clinar) isSelected(details *gitlab.RunnerDetails) bool {
+	grpsNprojs := []abstractRunnerLocation{}
+	for _, grp := range details.Groups {
+		grpsNprojs = append(grpsNprojs, abstractRunnerLocation{grp.ID, grp.Name, groupPath(grp.WebURL)})
+	}
+	for _, proj := range details.Projects {
+		grpsNprojs = append(grpsNprojs, abstractRunnerLocation{proj.ID, proj.Name, proj.PathWithNamespace})
+	}
+
+	if c.isProtected(details.TagList) {
+		c.Logger.Infof("Skipping %d, runner is protected by tag", details.ID)
+	} else if !c.isInScope(details) {
+		c.Logger.Debugf("Skipping %d, runner doesn't belong to the selected groups or projects", details.ID)
+	} else if c.isExcluded(grpsNprojs) || c.isExcludedByTags(details.TagList) {
+		c.Logger.Infof("Skipping %d", details.ID)
+	} else if !c.isSelectedState(details) {
+		c.Logger.Debugf("Skipping %d, runner status %s isn't selected", details.ID, details.Status)
+	} else if !c.isSelectedType(details.RunnerType) {
+		c.Logger.Debugf("Skipping %d, runner type %s isn't selected", details.ID, details.RunnerType)
+	} else if !c.isSelectedVersion(details.Version) {
+		c.Logger.Debugf("Skipping %d, runner version %s isn't selected", details.ID, details.Version)
+	} else if !c.isSelectedPlatform(details.Platform, details.Architecture) {
+		c.Logger.Debugf("Skipping %d, runner platform %s/%s isn't selected", details.ID, details.Platform, details.Architecture)
+	} else if !c.isOutdated(details) {
+		c.Logger.Debugf("Skipping %d, last contact is too recent", details.ID)
+	} else if !c.matchesWhere(details) {
+		c.Logger.Debugf("Skipping %d, where expression doesn't match", details.ID)
+	} else {
+		return c.isIncluded(grpsNprojs) && c.isIncludedByTags(details.TagList)
+	}
+	return false
+}
+
+// GetAllRunners returns all runners in one of the configured States. If no
+// States are configured only offline runners are returned. Runners matching
+// multiple states are only returned once. If AllRunners is set all runners of
+// the GitLab instance are listed which requires an administrator token. If
+// Groups or Projects are set only runners of those are listed. Instance
+// runners are never part of a group or project scope.
+func (c *Clinar) GetAllRunners(ctx context.Context) ([]*gitlab.Runner, error) {
+	states := c.states()
+
+	sources, err := c.runnerSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runners := []*gitlab.Runner{}
+	seen := map[int]bool{}
+	for _, list := range sources {
+		for _, state := range states {
+			rners, err := c.listRunners(ctx, list, listRunnersOptions(state, c.Types))
+			if err != nil {
+				return nil, err
 			}
-			if c.isExcluded(grpsNprojs) {
-				c.Logger.Infof("Skipping %d", rner.ID)
-			} else {
-				if c.isIncluded(grpsNprojs) {
-					runnerDetails = append(runnerDetails, details)
+			for _, rner := range c.filterTypes(rners) {
+				if c.isScoped() && rner.RunnerType == instanceRunnerType {
+					continue
+				}
+				if !seen[rner.ID] {
+					seen[rner.ID] = true
+					runners = append(runners, rner)
 				}
 			}
 		}
 	}
-	return runnerDetails
+	c.TotalRunners = len(runners)
+
+	return runners, nil
 }
 
-func (c *Clinar) GetAllRunners() ([]*gitlab.Runner, error) {
-	runners := []*gitlab.Runner{}
+// states returns the configured States or the default state if none are configured.
+func (c Clinar) states() []string {
+	if len(c.States) == 0 {
+		return []string{defaultRunnerState}
+	}
+	return c.States
+}
+
+// isSelectedState returns true if the runner is in one of the configured
+// States. Paused runners match the paused state whether they are online or not,
+// all other states except online only match offline runners.
+func (c Clinar) isSelectedState(details *gitlab.RunnerDetails) bool {
+	for _, state := range c.states() {
+		switch state {
+		case pausedRunnerState:
+			if details.Paused {
+				return true
+			}
+		case "online":
+			if details.Online {
+				return true
+			}
+		default:
+			if !details.Online {
+				return true
+			}
+		}
+	}
+	return false
+}
 
+// listRunnersOptions returns the options to list runners with the given state.
+// Paused isn't a status anymore so it is translated into the paused option. The
+// API only supports filtering by one type, so types are only passed on if
+// exactly one is given.
+func listRunnersOptions(state string, types []string) *gitlab.ListRunnersOptions {
 	opts := &gitlab.ListRunnersOptions{
 		ListOptions: gitlab.ListOptions{
 			PerPage: 100,
 			Page:    1,
 		},
-		Status: gitlab.Ptr(runnerState),
 	}
+	if len(types) == 1 {
+		opts.Type = gitlab.Ptr(types[0])
+	}
+	if state == pausedRunnerState {
+		opts.Paused = gitlab.Ptr(true)
+	} else {
+		opts.Status = gitlab.Ptr(state)
+	}
+	return opts
+}
 
-	rners, resp, err := c.Client.ListRunners(opts)
+// verifyAdmin returns an error if the token owner isn't an administrator.
+func (c *Clinar) verifyAdmin(ctx context.Context) error {
+	user, _, err := c.Client.CurrentUser(ctx)
 	if err != nil {
+		return err
+	}
+	if !user.IsAdmin {
+		return fmt.Errorf("user %s is not an administrator, listing all runners requires admin rights", user.Username)
+	}
+	return nil
+}
+
+// listRunners lists all pages of runners. If the response contains the total
+// number of pages the remaining pages are fetched in parallel. GitLab omits the
+// totals for large collections, in that case the next page (or keyset link) is
+// followed until the last page. Runners which are returned on more than one
+// page are only listed once.
+func (c *Clinar) listRunners(ctx context.Context, list listRunnersFunc, opts *gitlab.ListRunnersOptions) ([]*gitlab.Runner, error) {
+	rners, resp, err := list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages [][]*gitlab.Runner
+	if resp.TotalPages > 0 {
+		pages = c.listRemainingPages(ctx, list, opts, resp.TotalPages)
+	} else {
+		pages = c.followNextPages(ctx, list, opts, resp)
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	runners = append(runners, rners...)
 
-	results := make(chan listRunnerResultWrapper, resp.TotalPages)
+	runners := []*gitlab.Runner{}
+	seen := map[int]bool{}
+	for _, page := range append([][]*gitlab.Runner{rners}, pages...) {
+		for _, rner := range page {
+			if !seen[rner.ID] {
+				seen[rner.ID] = true
+				runners = append(runners, rner)
+			}
+		}
+	}
+	return runners, nil
+}
+
+// listRemainingPages fetches the pages 2 to totalPages in parallel.
+func (c *Clinar) listRemainingPages(ctx context.Context, list listRunnersFunc, opts *gitlab.ListRunnersOptions, totalPages int) [][]*gitlab.Runner {
+	results := make(chan listRunnerResultWrapper, totalPages)
 	var wg sync.WaitGroup
-	for i := 2; i <= resp.TotalPages; i++ {
+	for i := 2; i <= totalPages; i++ {
 		opts.Page = i
 		wg.Add(1)
-		go c.wrapListRunners(*opts, results, &wg)
+		go c.wrapListRunners(ctx, list, *opts, results, &wg)
 	}
 	wg.Wait()
 	close(results)
 
+	pages := [][]*gitlab.Runner{}
 	for rnerResult := range results {
 		if rnerResult.err != nil {
 			c.Logger.Error(rnerResult.err)
 		} else {
-			runners = append(runners, rnerResult.rners...)
+			pages = append(pages, rnerResult.rners)
 		}
 	}
+	return pages
+}
 
-	return runners, nil
+// followNextPages fetches the pages after resp one by one using the keyset
+// link or the next page of the previous response. An error stops the listing
+// as the following pages are unknown.
+func (c *Clinar) followNextPages(ctx context.Context, list listRunnersFunc, opts *gitlab.ListRunnersOptions, resp *gitlab.Response) [][]*gitlab.Runner {
+	pages := [][]*gitlab.Runner{}
+	for {
+		options := []gitlab.RequestOptionFunc{}
+		if resp.NextLink != "" {
+			options = append(options, gitlab.WithKeysetPaginationParameters(resp.NextLink))
+		} else if resp.NextPage > opts.Page {
+			opts.Page = resp.NextPage
+		} else {
+			return pages
+		}
+
+		rners, nextResp, err := list(ctx, opts, options...)
+		if err != nil {
+			c.Logger.Errorf("Error %s listing runners, the following pages are skipped", err)
+			return pages
+		}
+		pages = append(pages, rners)
+		if nextResp.NextLink != "" && nextResp.NextLink == resp.NextLink {
+			return pages
+		}
+		resp = nextResp
+	}
 }
 
-func (c Clinar) wrapListRunners(opts gitlab.ListRunnersOptions, results chan<- listRunnerResultWrapper, wg *sync.WaitGroup) {
-	rners, _, err := c.Client.ListRunners(&opts)
+func (c Clinar) wrapListRunners(ctx context.Context, list listRunnersFunc, opts gitlab.ListRunnersOptions, results chan<- listRunnerResultWrapper, wg *sync.WaitGroup) {
+	rners, _, err := list(ctx, &opts)
 	results <- listRunnerResultWrapper{rners, err}
 	wg.Done()
 }
 
-func (c *Clinar) CleanupRunners(staleRunnerIDs []*gitlab.RunnerDetails) {
+// CleanupRunners deletes the given runners. The runners are backed up to
+// BackupDir before the first runner is deleted. The details of each runner are
+// fetched again right before it is deleted, runners which don't match the
+// selection anymore are skipped. The returned Report contains the outcome of
+// each runner. If the runners exceed MaxDelete or MaxDeletePercent nothing is
+// deleted and an error is returned. If ctx is done no further deletions are
+// started, running deletions are finished and the remaining runners are
+// reported as skipped.
+func (c *Clinar) CleanupRunners(ctx context.Context, staleRunnerIDs []*gitlab.RunnerDetails) (*Report, error) {
+	return c.cleanupRunners(ctx, staleRunnerIDs, c.reverify)
+}
+
+// cleanupRunners deletes the runners like CleanupRunners but re-checks each
+// runner with verify right before it is deleted.
+func (c *Clinar) cleanupRunners(ctx context.Context, staleRunnerIDs []*gitlab.RunnerDetails, verify verifyFunc) (*Report, error) {
+	report := &Report{Results: []DeletionResult{}}
 	if len(staleRunnerIDs) == 0 {
 		c.Logger.Info("No runners to be purged!")
+		return report, nil
+	}
+	if err := c.checkLimits(len(staleRunnerIDs)); err != nil {
+		return nil, err
 	}
+	if c.BackupDir != "" && ctx.Err() == nil {
+		path, err := WriteBackup(c.BackupDir, c.Host, staleRunnerIDs)
+		if err != nil {
+			return nil, fmt.Errorf("error %s writing backup, no runners deleted", err)
+		}
+		c.Logger.Infof("Backed up %d runners to %s", len(staleRunnerIDs), path)
+	}
+
+	results := make([]*DeletionResult, len(staleRunnerIDs))
+	var limiter *rate.Limiter
+	if c.DeleteRate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(c.DeleteRate), 1)
+	}
+	batchSize := c.batchSize(len(staleRunnerIDs))
+	for start := 0; start < len(staleRunnerIDs); start += batchSize {
+		if start > 0 && c.BatchPause > 0 {
+			c.Logger.Infof("Deleted %d of %d runners, pausing for %s", start, len(staleRunnerIDs), c.BatchPause)
+			select {
+			case <-ctx.Done():
+			case <-time.After(c.BatchPause):
+			}
+		}
+		end := min(start+batchSize, len(staleRunnerIDs))
+		c.deleteRunners(ctx, staleRunnerIDs[start:end], results[start:end], limiter, verify)
+	}
+
+	for i, result := range results {
+		if result == nil {
+			c.interrupted(report, staleRunnerIDs[i])
+			continue
+		}
+		report.add(*result)
+	}
+	if report.Interrupted {
+		c.Logger.Warn("Interrupted, no further runners were deleted")
+	}
+	c.Logger.Infof("Deleted %d runners, skipped %d runners which changed since they were selected", report.Deleted, report.Skipped)
+	return report, nil
+}
+
+// interrupted records the runner as skipped because ctx was done before it was deleted.
+func (c *Clinar) interrupted(report *Report, rner *gitlab.RunnerDetails) {
+	report.Interrupted = true
+	c.record(report, rner, DeletionResult{ID: rner.ID, Description: rner.Description, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"})
+}
+
+// record adds the result to the report and writes it to the AuditLog.
+func (c *Clinar) record(report *Report, rner *gitlab.RunnerDetails, result DeletionResult) {
+	report.add(c.audit(rner, result))
+}
+
+// audit sets the retries of the result and writes it to the AuditLog. It is
+// called by the delete workers as soon as a runner is deleted so that no record
+// is lost if clinar is terminated during a long run.
+func (c *Clinar) audit(rner *gitlab.RunnerDetails, result DeletionResult) DeletionResult {
+	if counter, ok := c.Client.(retryCounter); ok {
+		result.Retries = counter.Retries(rner.ID)
+	}
+	if c.AuditLog != nil {
+		if err := c.AuditLog.Write(rner, result); err != nil {
+			c.Logger.Errorf("Error %s writing audit log for runner ID %d", err, rner.ID)
+		}
+	}
+	return result
+}
 
-	result := make(chan responseWrapper, len(staleRunnerIDs))
+// checkLimits returns an error if deleting count runners exceeds MaxDelete or
+// MaxDeletePercent of TotalRunners. If Force is set the limits are ignored.
+func (c *Clinar) checkLimits(count int) error {
+	var err error
+	if c.MaxDelete > 0 && count > c.MaxDelete {
+		err = fmt.Errorf("%d runners selected for deletion, which exceeds the limit of %d runners", count, c.MaxDelete)
+	} else if c.MaxDeletePercent > 0 {
+		total := c.TotalRunners
+		if total < count {
+			total = count
+		}
+		percent := float64(count) * 100 / float64(total)
+		if percent > c.MaxDeletePercent {
+			err = fmt.Errorf("%d of %d runners (%.1f%%) selected for deletion, which exceeds the limit of %.1f%%", count, total, percent, c.MaxDeletePercent)
+		}
+	}
+
+	if err != nil && c.Force {
+		c.Logger.Warnf("%s, continuing because of force", err)
+		return nil
+	}
+	return err
+}
+
+// reverify fetches the current details of the runner and returns the reason
+// why it doesn't match the selection anymore or an empty string if it still does.
+// A quarantined runner must still carry the same quarantine marker.
+func (c *Clinar) reverify(ctx context.Context, rner *gitlab.RunnerDetails) string {
+	current, _, err := c.Client.GetRunnerDetails(ctx, rner.ID)
+	if err != nil {
+		return fmt.Sprintf("error %s getting current runner details", err)
+	}
+	if current.Online && !c.isSelectedState(current) {
+		return "runner is online again"
+	}
+	if since, quarantined := quarantinedSince(rner.MaintenanceNote); quarantined {
+		if currentSince, stillQuarantined := quarantinedSince(current.MaintenanceNote); !stillQuarantined || !currentSince.Equal(since) {
+			return "runner was released from quarantine"
+		}
+	}
+	if !c.isSelected(current) {
+		return "runner doesn't match the selection anymore"
+	}
+	return ""
+}
+
+// deleteRunners deletes the runners with DeleteParallelism workers and writes
+// the result of each runner to the same index of results. Runners which aren't
+// deleted because ctx is done are left empty in results.
+func (c *Clinar) deleteRunners(ctx context.Context, rners []*gitlab.RunnerDetails, results []*DeletionResult, limiter *rate.Limiter, verify verifyFunc) {
+	jobs := make(chan int)
 	var wg sync.WaitGroup
-	for _, rner := range staleRunnerIDs {
-		c.Logger.Infof("Deleting %d - %s", rner.ID, rner.Name)
+	for i := 0; i < min(c.deleteParallelism(), len(rners)); i++ {
 		wg.Add(1)
-		c.wrapDeleteRegisteredRunnerById(*rner, result, &wg)
+		go c.deleteRunnersWorker(ctx, rners, jobs, results, limiter, verify, &wg)
+	}
+	for i := range rners {
+		jobs <- i
 	}
+	close(jobs)
 	wg.Wait()
-	close(result)
+}
 
-	for deleteResult := range result {
-		if deleteResult.err != nil {
-			c.Logger.Error(deleteResult.err)
+// deleteRunnersWorker verifies each runner right before it is deleted and skips
+// runners which changed since they were selected. It
+// doesn't start deletions once ctx is done. Started deletions aren't cancelled
+// so that it is known if the runner was deleted.
+func (c *Clinar) deleteRunnersWorker(ctx context.Context, rners []*gitlab.RunnerDetails, jobs <-chan int, results []*DeletionResult, limiter *rate.Limiter, verify verifyFunc, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for i := range jobs {
+		if ctx.Err() != nil {
+			continue
+		}
+		reason := verify(ctx, rners[i])
+		if ctx.Err() != nil {
+			continue
 		}
-		c.Logger.Debugf("DeleteRegisteredRunnerByID returned status %s\n", deleteResult.resp.Status)
+		if reason != "" {
+			c.Logger.Warnf("Skipping %d - %s, %s", rners[i].ID, rners[i].Name, reason)
+			result := c.audit(rners[i], DeletionResult{ID: rners[i].ID, Description: rners[i].Description, Outcome: OutcomeSkipped, Reason: reason})
+			results[i] = &result
+			continue
+		}
+		if limiter != nil && limiter.Wait(ctx) != nil {
+			continue
+		}
+		c.Logger.Infof("Deleting %d - %s", rners[i].ID, rners[i].Name)
+		resp, err := c.Client.DeleteRegisteredRunnerByID(context.WithoutCancel(ctx), rners[i].ID)
+		result := c.audit(rners[i], c.deletionResult(rners[i], resp, err))
+		results[i] = &result
 	}
 }
 
-func (c Clinar) wrapDeleteRegisteredRunnerById(rner gitlab.RunnerDetails, result chan<- responseWrapper, wg *sync.WaitGroup) {
-	resp, err := c.Client.DeleteRegisteredRunnerByID(rner.ID)
-	result <- responseWrapper{*resp, err}
-	wg.Done()
+// deletionResult returns the outcome of the delete request. resp can be nil
+// e.g. on transport errors.
+func (c *Clinar) deletionResult(rner *gitlab.RunnerDetails, resp *gitlab.Response, err error) DeletionResult {
+	result := DeletionResult{ID: rner.ID, Description: rner.Description, Outcome: OutcomeDeleted}
+	if resp != nil && resp.Response != nil {
+		result.StatusCode = resp.StatusCode
+		c.Logger.Debugf("DeleteRegisteredRunnerByID returned status %s\n", resp.Status)
+	}
+	if err != nil {
+		c.Logger.Error(err)
+		result.Outcome = OutcomeFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (c Clinar) deleteParallelism() int {
+	if c.DeleteParallelism < 1 {
+		return 1
+	}
+	return c.DeleteParallelism
+}
+
+// batchSize returns BatchSize or all runners if no BatchSize is set.
+func (c Clinar) batchSize(runners int) int {
+	if c.BatchSize < 1 {
+		return runners
+	}
+	return c.BatchSize
 }
 
 func (c Clinar) isExcluded(locations []abstractRunnerLocation) bool {
 	for _, filter := range c.ExcludeFilter {
 		for _, loc := range locations {
-			if filter == loc.name {
-				return true
-			} else if filter == strconv.Itoa(loc.id) {
+			if matchesFilter(filter, loc) {
 				return true
 			}
 		}
@@ -143,10 +589,22 @@ func (c Clinar) isIncluded(locations []abstractRunnerLocation) bool {
 		return true
 	} else {
 		for _, loc := range locations {
-			if c.IncludePattern.MatchString(loc.name) {
+			if c.IncludePattern.MatchString(loc.name) || (loc.path != "" && c.IncludePattern.MatchString(loc.path)) {
 				return true
 			}
 		}
 	}
 	return false
 }
+
+// isOutdated checks the last contact of the runner against OlderThan. Runners
+// which never contacted GitLab are only considered if SkipNeverContacted is not set.
+func (c Clinar) isOutdated(details *gitlab.RunnerDetails) bool {
+	if details.ContactedAt == nil {
+		return !c.SkipNeverContacted
+	}
+	if c.OlderThan == 0 {
+		return true
+	}
+	return details.ContactedAt.Before(time.Now().Add(-c.OlderThan))
+}
@@ -1,16 +1,20 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	logrusTest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/steffakasid/clinar/mocks"
 	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
@@ -22,7 +26,8 @@ func TestGetRunnerDetails(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockGetRunnerDetails(mock, 1)
 		clinar := Clinar{Client: mock, Logger: logger}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}})
+		require.NoError(t, err)
 		assert.Len(t, details, 1)
 		assert.Equal(t, "someRunner1", details[0].Name)
 		mock.AssertExpectations(t)
@@ -32,7 +37,8 @@ func TestGetRunnerDetails(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockGetRunnerDetails(mock, 3)
 		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"Project2"}}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
 		assert.Len(t, details, 2)
 		assert.Equal(t, "someRunner1", details[0].Name)
 		assert.Equal(t, "someRunner3", details[1].Name)
@@ -43,7 +49,8 @@ func TestGetRunnerDetails(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockGetRunnerDetails(mock, 3)
 		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"22"}}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
 		assert.Len(t, details, 2)
 		assert.Equal(t, "someRunner1", details[0].Name)
 		assert.Equal(t, "someRunner3", details[1].Name)
@@ -54,7 +61,8 @@ func TestGetRunnerDetails(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockGetRunnerDetails(mock, 3)
 		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"Group1"}}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
 		assert.Len(t, details, 2)
 		assert.Equal(t, "someRunner2", details[0].Name)
 		assert.Equal(t, "someRunner3", details[1].Name)
@@ -65,36 +73,212 @@ func TestGetRunnerDetails(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockGetRunnerDetails(mock, 3)
 		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"11"}}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
 		assert.Len(t, details, 2)
 		assert.Equal(t, "someRunner2", details[0].Name)
 		assert.Equal(t, "someRunner3", details[1].Name)
 		mock.AssertExpectations(t)
 	})
 
+	t.Run("Filter out project by glob", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mockGetRunnerDetails(mock, 3)
+		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"glob:Project[12]"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, "someRunner3", details[0].Name)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Filter out group by regex", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mockGetRunnerDetails(mock, 3)
+		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"regex:^Group[23]$"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, "someRunner1", details[0].Name)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Filter out descendants of parent group", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform/team-a", ""), &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(runnerDetailsWithPaths(2, "", "platform/team-b/infra"), &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(runnerDetailsWithPaths(3, "", "other/infra"), &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, ExcludeFilter: []string{"platform"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, 3, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
 	t.Run("Include filter", func(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockGetRunnerDetails(mock, 4)
 		clinar := Clinar{Client: mock, Logger: logger, IncludePattern: regexp.MustCompile(".*roject[3,4]")}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}})
+		require.NoError(t, err)
 		assert.Len(t, details, 2)
 		assert.Equal(t, "someRunner3", details[0].Name)
 		assert.Equal(t, "someRunner4", details[1].Name)
 		mock.AssertExpectations(t)
 	})
 
+	t.Run("Filter by last contact", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Name: "someRunner1", ContactedAt: gitlab.Ptr(time.Now().Add(-48 * time.Hour))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Name: "someRunner2", ContactedAt: gitlab.Ptr(time.Now().Add(-10 * time.Minute))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3, Name: "someRunner3"}, &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, OlderThan: 24 * time.Hour}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 2)
+		assert.Equal(t, "someRunner1", details[0].Name)
+		assert.Equal(t, "someRunner3", details[1].Name)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Skip never contacted runners", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Name: "someRunner1", ContactedAt: gitlab.Ptr(time.Now().Add(-48 * time.Hour))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Name: "someRunner2"}, &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, OlderThan: 24 * time.Hour, SkipNeverContacted: true}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, "someRunner1", details[0].Name)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Filter by runner type", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Name: "someRunner1", RunnerType: "project_type"}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Name: "someRunner2", RunnerType: "instance_type"}, &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, Types: []string{"project_type"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, "someRunner1", details[0].Name)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Filter by version and platform", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Version: "15.11.1", Platform: "linux", Architecture: "amd64"}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Version: "16.5.0", Platform: "linux", Architecture: "amd64"}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3, Version: "15.2.0", Platform: "windows", Architecture: "amd64"}, &gitlab.Response{}, nil).Once()
+		maxVersion, err := ParseVersion("16.0.0")
+		require.NoError(t, err)
+		clinar := Clinar{Client: mock, Logger: logger, MaxVersion: maxVersion, Platforms: []string{"linux"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		assert.Equal(t, 1, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Include tags any of", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mockGetRunnerDetailsWithTags(mock, []string{"docker"}, []string{"gpu", "k8s-prod"}, []string{"shell"})
+		clinar := Clinar{Client: mock, Logger: logger, IncludeTags: []string{"docker", "gpu"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 2)
+		assert.Equal(t, 1, details[0].ID)
+		assert.Equal(t, 2, details[1].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Include tags all of", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mockGetRunnerDetailsWithTags(mock, []string{"docker"}, []string{"docker", "gpu"}, []string{"gpu"})
+		clinar := Clinar{Client: mock, Logger: logger, IncludeTags: []string{"docker", "gpu"}, TagMatch: TagMatchAll}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, 2, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Exclude tags", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mockGetRunnerDetailsWithTags(mock, []string{"docker"}, []string{"docker", "k8s-prod"}, nil)
+		clinar := Clinar{Client: mock, Logger: logger, IncludeTags: []string{"docker"}, ExcludeTags: []string{"k8s-prod"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, 1, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Protect tag", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mockGetRunnerDetailsWithTags(mock, []string{"docker", "clinar-keep"}, []string{"docker"})
+		clinar := Clinar{Client: mock, Logger: logger, IncludeTags: []string{"docker"}, ProtectTags: []string{"clinar-keep"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		assert.Len(t, details, 1)
+		assert.Equal(t, 2, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
 	t.Run("Error from GetRunnerDetails", func(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		logHook.Reset()
-		mock.EXPECT().GetRunnerDetails(1).Return(nil, &gitlab.Response{}, errors.New("Something went wrong")).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(nil, &gitlab.Response{}, errors.New("Something went wrong")).Once()
 		clinar := Clinar{Client: mock, Logger: logger}
-		details := clinar.GetRunnerDetails([]*gitlab.Runner{{ID: 1}})
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}})
+		require.NoError(t, err)
 		assert.Len(t, details, 0)
 		assert.Len(t, logHook.Entries, 1)
 		assert.Equal(t, "Error Something went wrong getting runner details for runner ID 1", logHook.Entries[0].Message)
 		assert.Equal(t, logrus.ErrorLevel, logHook.Entries[0].Level)
 		mock.AssertExpectations(t)
 	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logHook.Reset()
+		rners := []*gitlab.Runner{}
+		for i := 1; i <= 50; i++ {
+			rners = append(rners, &gitlab.Runner{ID: i})
+			if i%10 == 0 {
+				mock.EXPECT().GetRunnerDetails(testifyMock.Anything, i).Return(nil, &gitlab.Response{}, fmt.Errorf("Something went wrong %d", i)).Once()
+			} else {
+				mock.EXPECT().GetRunnerDetails(testifyMock.Anything, i).Return(&gitlab.RunnerDetails{ID: i, Projects: runnerDetailsWithPaths(i, "", fmt.Sprintf("group/project%d", i%3)).Projects}, &gitlab.Response{}, nil).Once()
+			}
+		}
+		clinar := Clinar{Client: mock, Logger: logger, Concurrency: 8, ExcludeFilter: []string{"group/project0"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), rners)
+		require.NoError(t, err)
+
+		ids := []int{}
+		for _, d := range details {
+			ids = append(ids, d.ID)
+		}
+		expected := []int{}
+		for i := 1; i <= 50; i++ {
+			if i%10 != 0 && i%3 != 0 {
+				expected = append(expected, i)
+			}
+		}
+		assert.Equal(t, expected, ids)
+		errs := []string{}
+		for _, entry := range logHook.Entries {
+			if entry.Level == logrus.ErrorLevel {
+				errs = append(errs, entry.Message)
+			}
+		}
+		require.Len(t, errs, 5)
+		for i, msg := range errs {
+			assert.Equal(t, fmt.Sprintf("Error Something went wrong %d getting runner details for runner ID %d", (i+1)*10, (i+1)*10), msg)
+		}
+		mock.AssertExpectations(t)
+	})
 }
 
 func TestGetAllRunners(t *testing.T) {
@@ -104,7 +288,7 @@ func TestGetAllRunners(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockListRunners(mock, 1)
 		clinar := Clinar{Client: mock, Logger: logger}
-		rners, err := clinar.GetAllRunners()
+		rners, err := clinar.GetAllRunners(context.Background())
 		require.NoError(t, err)
 		assert.Len(t, rners, 10)
 		mock.AssertExpectations(t)
@@ -115,21 +299,97 @@ func TestGetAllRunners(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockListRunners(mock, 10)
 		clinar := Clinar{Client: mock, Logger: logger}
-		rners, err := clinar.GetAllRunners()
+		rners, err := clinar.GetAllRunners(context.Background())
 		require.NoError(t, err)
 		require.Len(t, rners, 100)
+		assert.Equal(t, 100, clinar.TotalRunners)
 		assertRunnerIDContained(t, rners, 50)
 		assertRunnerIDContained(t, rners, 75)
 		assertRunnerIDContained(t, rners, 100)
 		mock.AssertExpectations(t)
 	})
 
+	t.Run("Multiple states", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mockListRunnersWithState(mock, "offline", 2)
+		mockListRunnersWithState(mock, "stale", 1)
+		clinar := Clinar{Client: mock, Logger: logger, States: []string{"offline", "stale"}}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 20)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Paused runners", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListRunners(testifyMock.Anything, &gitlab.ListRunnersOptions{
+			ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1},
+			Paused:      gitlab.Ptr(true),
+		}).Return([]*gitlab.Runner{{ID: 1}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, States: []string{"paused"}}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 1)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Single runner type", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListRunners(testifyMock.Anything, listRunnersOptions(defaultRunnerState, []string{"project_type"})).
+			Return([]*gitlab.Runner{{ID: 1, RunnerType: "project_type"}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, Types: []string{"project_type"}}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 1)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Multiple runner types", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListRunners(testifyMock.Anything, listRunnersOptions(defaultRunnerState, nil)).
+			Return([]*gitlab.Runner{{ID: 1, RunnerType: "project_type"}, {ID: 2, RunnerType: "instance_type"}, {ID: 3, RunnerType: "group_type"}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, Types: []string{"project_type", "group_type"}}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		require.Len(t, rners, 2)
+		assert.Equal(t, 1, rners[0].ID)
+		assert.Equal(t, 3, rners[1].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("All runners as admin", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().CurrentUser(testifyMock.Anything).Return(&gitlab.User{Username: "root", IsAdmin: true}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().ListAllRunners(testifyMock.Anything, listRunnersOptions(defaultRunnerState, nil)).Return([]*gitlab.Runner{{ID: 1}, {ID: 2}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, AllRunners: true}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 2)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("All runners without admin rights", func(t *testing.T) {
+		logger, _ := logrusTest.NewNullLogger()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().CurrentUser(testifyMock.Anything).Return(&gitlab.User{Username: "someone"}, &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, AllRunners: true}
+		rners, err := clinar.GetAllRunners(context.Background())
+		assert.Nil(t, rners)
+		assert.EqualError(t, err, "user someone is not an administrator, listing all runners requires admin rights")
+		mock.AssertExpectations(t)
+	})
+
 	t.Run("Error at first call", func(t *testing.T) {
 		logger, _ := logrusTest.NewNullLogger()
 		mock := &mocks.GitLabClient{}
 		mockListRunners(mock, 1, 1)
 		clinar := Clinar{Client: mock, Logger: logger}
-		rners, err := clinar.GetAllRunners()
+		rners, err := clinar.GetAllRunners(context.Background())
 		assert.Error(t, err)
 		assert.Nil(t, rners)
 		assert.EqualError(t, err, "Something went wrong 1")
@@ -141,7 +401,7 @@ func TestGetAllRunners(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		mockListRunners(mock, 10, 3)
 		clinar := Clinar{Client: mock, Logger: logger}
-		rners, err := clinar.GetAllRunners()
+		rners, err := clinar.GetAllRunners(context.Background())
 		require.NoError(t, err)
 		assert.Len(t, rners, 90)
 		assert.Len(t, logHook.Entries, 1)
@@ -150,20 +410,76 @@ func TestGetAllRunners(t *testing.T) {
 	})
 }
 
+func TestListRunnersWithoutTotalPages(t *testing.T) {
+	page := func(n int) interface{} {
+		return testifyMock.MatchedBy(func(opts *gitlab.ListRunnersOptions) bool { return opts.Page == n })
+	}
+
+	t.Run("Follow next page", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(1)).Return([]*gitlab.Runner{{ID: 1}, {ID: 2}}, &gitlab.Response{NextPage: 2}, nil).Once()
+		// Runner 2 moved to the second page while listing
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(2)).Return([]*gitlab.Runner{{ID: 2}, {ID: 3}}, &gitlab.Response{NextPage: 3}, nil).Once()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(3)).Return([]*gitlab.Runner{{ID: 4}}, &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		ids := []int{}
+		for _, rner := range rners {
+			ids = append(ids, rner.ID)
+		}
+		assert.Equal(t, []int{1, 2, 3, 4}, ids)
+		assert.Equal(t, 4, clinar.TotalRunners)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Follow keyset link", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(1)).Return([]*gitlab.Runner{{ID: 1}}, &gitlab.Response{NextLink: "https://gitlab.com/api/v4/runners?cursor=a"}, nil).Once()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(1), testifyMock.Anything).Return([]*gitlab.Runner{{ID: 2}}, &gitlab.Response{NextLink: "https://gitlab.com/api/v4/runners?cursor=b"}, nil).Once()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(1), testifyMock.Anything).Return([]*gitlab.Runner{{ID: 3}}, &gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 3)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Error on next page", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, logHook := logrusTest.NewNullLogger()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(1)).Return([]*gitlab.Runner{{ID: 1}}, &gitlab.Response{NextPage: 2}, nil).Once()
+		mock.EXPECT().ListRunners(testifyMock.Anything, page(2)).Return(nil, &gitlab.Response{}, errors.New("Something went wrong")).Once()
+		clinar := Clinar{Client: mock, Logger: logger}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 1)
+		assert.Equal(t, "Error Something went wrong listing runners, the following pages are skipped", logHook.LastEntry().Message)
+		mock.AssertExpectations(t)
+	})
+}
+
 func TestCleanupRunners(t *testing.T) {
 	t.Run("Simple case", func(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		logger, logHook := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 5)
 		mockDeleteRegisteredRunnerByID(mock, 5)
 		clinar := Clinar{Client: mock, Logger: logger}
-		clinar.CleanupRunners([]*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}})
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}})
+		require.NoError(t, err)
 		mock.AssertExpectations(t)
-		assert.Len(t, logHook.Entries, 5)
+		assert.Equal(t, 5, report.Deleted)
+		assert.Equal(t, ExitOK, report.ExitCode())
+		assert.Len(t, logHook.Entries, 6)
 		assert.Contains(t, logHook.Entries[0].Message, "Deleting")
 		assert.Contains(t, logHook.Entries[1].Message, "Deleting")
 		assert.Contains(t, logHook.Entries[2].Message, "Deleting")
 		assert.Contains(t, logHook.Entries[3].Message, "Deleting")
 		assert.Contains(t, logHook.Entries[4].Message, "Deleting")
+		assert.Equal(t, "Deleted 5 runners, skipped 0 runners which changed since they were selected", logHook.Entries[5].Message)
 		// Wait 50ms until goroutines are finished
 	})
 
@@ -171,7 +487,10 @@ func TestCleanupRunners(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		logger, logHook := logrusTest.NewNullLogger()
 		clinar := Clinar{Client: mock, Logger: logger}
-		clinar.CleanupRunners([]*gitlab.RunnerDetails{})
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{})
+		require.NoError(t, err)
+		assert.Empty(t, report.Results)
+		assert.Equal(t, ExitNothingToDo, report.ExitCode())
 		mock.AssertExpectations(t)
 		assert.Len(t, logHook.Entries, 1)
 		assert.Equal(t, "No runners to be purged!", logHook.Entries[0].Message)
@@ -181,17 +500,271 @@ func TestCleanupRunners(t *testing.T) {
 	t.Run("Error from DeleteRegisteredRunnerByID", func(t *testing.T) {
 		mock := &mocks.GitLabClient{}
 		logger, logHook := logrusTest.NewNullLogger()
-		mock.EXPECT().DeleteRegisteredRunnerByID(123).Return(&gitlab.Response{Response: &http.Response{Status: "200 OK"}}, errors.New("Something went wrong"))
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 123).Return(&gitlab.RunnerDetails{ID: 123}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 123).Return(&gitlab.Response{Response: &http.Response{Status: "500 Internal Server Error", StatusCode: 500}}, errors.New("Something went wrong"))
 		clinar := Clinar{Client: mock, Logger: logger}
-		clinar.CleanupRunners([]*gitlab.RunnerDetails{{ID: 123}})
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 123}})
+		require.NoError(t, err)
 		mock.AssertExpectations(t)
-		assert.Len(t, logHook.Entries, 2)
+		assert.Equal(t, []DeletionResult{{ID: 123, Outcome: OutcomeFailed, StatusCode: 500, Error: "Something went wrong"}}, report.Results)
+		assert.Equal(t, ExitPartialFailure, report.ExitCode())
+		assert.Len(t, logHook.Entries, 3)
 		assert.Equal(t, "Deleting 123 - ", logHook.Entries[0].Message)
 		assert.Equal(t, logrus.InfoLevel, logHook.Entries[0].Level)
 		assert.Equal(t, "Something went wrong", logHook.Entries[1].Message)
 		assert.Equal(t, logrus.ErrorLevel, logHook.Entries[1].Level)
+		assert.Equal(t, "Deleted 0 runners, skipped 0 runners which changed since they were selected", logHook.Entries[2].Message)
+	})
+
+	t.Run("Error without response from DeleteRegisteredRunnerByID", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 2)
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(nil, errors.New("connection refused")).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 2).Return(&gitlab.Response{Response: &http.Response{Status: "204 No Content", StatusCode: 204}}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger}
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1, Description: "first"}, {ID: 2, Description: "second"}})
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+		assert.Equal(t, []DeletionResult{
+			{ID: 1, Description: "first", Outcome: OutcomeFailed, Error: "connection refused"},
+			{ID: 2, Description: "second", Outcome: OutcomeDeleted, StatusCode: 204},
+		}, report.Results)
+		assert.Equal(t, ExitPartialFailure, report.ExitCode())
+	})
+
+	t.Run("Exceeds max delete", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		clinar := Clinar{Client: mock, Logger: logger, MaxDelete: 2}
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}})
+		assert.EqualError(t, err, "3 runners selected for deletion, which exceeds the limit of 2 runners")
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Exceeds max delete percent", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		clinar := Clinar{Client: mock, Logger: logger, MaxDeletePercent: 20, TotalRunners: 10}
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}})
+		assert.EqualError(t, err, "3 of 10 runners (30.0%) selected for deletion, which exceeds the limit of 20.0%")
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Force exceeding limits", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, logHook := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 3)
+		mockDeleteRegisteredRunnerByID(mock, 3)
+		clinar := Clinar{Client: mock, Logger: logger, MaxDelete: 2, Force: true}
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Equal(t, "3 runners selected for deletion, which exceeds the limit of 2 runners, continuing because of force", logHook.Entries[0].Message)
+		assert.Equal(t, logrus.WarnLevel, logHook.Entries[0].Level)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Within limits", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 2)
+		mockDeleteRegisteredRunnerByID(mock, 2)
+		clinar := Clinar{Client: mock, Logger: logger, MaxDelete: 2, MaxDeletePercent: 20, TotalRunners: 10}
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Skip runners which changed", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, logHook := logrusTest.NewNullLogger()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Online: true}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, ContactedAt: gitlab.Ptr(time.Now())}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3, TagList: []string{"clinar-keep"}}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 4).Return(nil, &gitlab.Response{}, errors.New("Something went wrong")).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 5).Return(&gitlab.RunnerDetails{ID: 5, ContactedAt: gitlab.Ptr(time.Now().Add(-48 * time.Hour))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 5).Return(&gitlab.Response{Response: &http.Response{Status: "204 No Content"}}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, OlderThan: 24 * time.Hour, ProtectTags: []string{"clinar-keep"}}
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}})
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+		assert.Equal(t, 1, report.Deleted)
+		assert.Equal(t, 4, report.Skipped)
+		assert.Equal(t, DeletionResult{ID: 1, Outcome: OutcomeSkipped, Reason: "runner is online again"}, report.Results[0])
+
+		messages := []string{}
+		for _, entry := range logHook.AllEntries() {
+			messages = append(messages, entry.Message)
+		}
+		assert.Contains(t, messages, "Skipping 1 - , runner is online again")
+		assert.Contains(t, messages, "Skipping 2 - , runner doesn't match the selection anymore")
+		assert.Contains(t, messages, "Skipping 3 - , runner doesn't match the selection anymore")
+		assert.Contains(t, messages, "Skipping 4 - , error Something went wrong getting current runner details")
+		assert.Contains(t, messages, "Deleting 5 - ")
+		assert.Equal(t, "Deleted 1 runners, skipped 4 runners which changed since they were selected", logHook.LastEntry().Message)
+	})
+
+	t.Run("Re-check each runner right before it is deleted", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		calls := []string{}
+		for i := 1; i <= 3; i++ {
+			mock.EXPECT().GetRunnerDetails(testifyMock.Anything, i).Run(func(_ context.Context, rid interface{}, options ...gitlab.RequestOptionFunc) {
+				calls = append(calls, fmt.Sprintf("get %v", rid))
+			}).Return(&gitlab.RunnerDetails{ID: i}, &gitlab.Response{}, nil).Once()
+			mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, i).Run(func(_ context.Context, rid int, options ...gitlab.RequestOptionFunc) {
+				calls = append(calls, fmt.Sprintf("delete %d", rid))
+			}).Return(&gitlab.Response{}, nil).Once()
+		}
+		clinar := Clinar{Client: mock, Logger: logger, BatchSize: 1, BatchPause: time.Millisecond}
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Deleted)
+		assert.Equal(t, []string{"get 1", "delete 1", "get 2", "delete 2", "get 3", "delete 3"}, calls)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Online paused runners", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Online: true, Paused: true}, &gitlab.Response{}, nil).Times(2)
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Online: true}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(&gitlab.Response{}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, States: []string{"offline", "paused"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		report, err := clinar.CleanupRunners(context.Background(), details)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Deleted)
+		mock.AssertExpectations(t)
+	})
+}
+
+func TestCleanupRunnersConcurrently(t *testing.T) {
+	t.Run("Parallelism", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 20)
+		var running, maxRunning int32
+		for i := 1; i <= 20; i++ {
+			call := mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, i).Run(func(_ context.Context, rid int, options ...gitlab.RequestOptionFunc) {
+				current := atomic.AddInt32(&running, 1)
+				for {
+					observed := atomic.LoadInt32(&maxRunning)
+					if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+			if i == 7 {
+				call.Return(nil, errors.New("connection reset")).Once()
+			} else {
+				call.Return(&gitlab.Response{}, nil).Once()
+			}
+		}
+		rners := []*gitlab.RunnerDetails{}
+		for i := 1; i <= 20; i++ {
+			rners = append(rners, &gitlab.RunnerDetails{ID: i})
+		}
+
+		clinar := Clinar{Client: mock, Logger: logger, DeleteParallelism: 4}
+		report, err := clinar.CleanupRunners(context.Background(), rners)
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+		assert.Equal(t, int32(4), maxRunning)
+		assert.Equal(t, 19, report.Deleted)
+		assert.Equal(t, 1, report.Failed)
+		for i, result := range report.Results {
+			assert.Equal(t, i+1, result.ID)
+		}
+		assert.Equal(t, OutcomeFailed, report.Results[6].Outcome)
+	})
+
+	t.Run("Rate limit", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 5)
+		mockDeleteRegisteredRunnerByID(mock, 5)
+		clinar := Clinar{Client: mock, Logger: logger, DeleteParallelism: 5, DeleteRate: 20}
+		start := time.Now()
+		_, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Batches", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, logHook := logrusTest.NewNullLogger()
+		mockGetRunnerDetails(mock, 5)
+		mockDeleteRegisteredRunnerByID(mock, 5)
+		clinar := Clinar{Client: mock, Logger: logger, DeleteParallelism: 5, BatchSize: 2, BatchPause: 50 * time.Millisecond}
+		start := time.Now()
+		report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		assert.Equal(t, 5, report.Deleted)
+		mock.AssertExpectations(t)
+
+		messages := []string{}
+		for _, entry := range logHook.AllEntries() {
+			messages = append(messages, entry.Message)
+		}
+		assert.Contains(t, messages, "Deleted 2 of 5 runners, pausing for 50ms")
+		assert.Contains(t, messages, "Deleted 4 of 5 runners, pausing for 50ms")
 	})
+}
+
+func TestCleanupRunnersInterrupted(t *testing.T) {
+	t.Run("Running deletions are finished", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, logHook := logrusTest.NewNullLogger()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockGetRunnerDetails(mock, 1)
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Run(func(ctx context.Context, rid int, options ...gitlab.RequestOptionFunc) {
+			cancel()
+			assert.NoError(t, ctx.Err())
+		}).Return(&gitlab.Response{}, nil).Once()
+
+		clinar := Clinar{Client: mock, Logger: logger}
+		report, err := clinar.CleanupRunners(ctx, []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+		assert.True(t, report.Interrupted)
+		assert.Equal(t, []DeletionResult{
+			{ID: 1, Outcome: OutcomeDeleted},
+			{ID: 2, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"},
+			{ID: 3, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"},
+		}, report.Results)
+		assert.Equal(t, ExitPartialFailure, report.ExitCode())
+
+		messages := []string{}
+		for _, entry := range logHook.AllEntries() {
+			messages = append(messages, entry.Message)
+		}
+		assert.Contains(t, messages, "Interrupted, no further runners were deleted")
+	})
+
+	t.Run("Nothing is started after cancel", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		logger, _ := logrusTest.NewNullLogger()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		clinar := Clinar{Client: mock, Logger: logger}
+		report, err := clinar.CleanupRunners(ctx, []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		mock.AssertExpectations(t)
+		assert.Equal(t, 2, report.Skipped)
+		assert.True(t, report.Interrupted)
 
+		_, err = clinar.GetRunnerDetails(ctx, []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 }
 
 func mockGetRunnerDetails(mock *mocks.GitLabClient, numOfCalls int) {
@@ -222,19 +795,46 @@ func mockGetRunnerDetails(mock *mocks.GitLabClient, numOfCalls int) {
 				},
 			},
 		}
-		mock.EXPECT().GetRunnerDetails(i).Return(details, &gitlab.Response{TotalItems: 1, TotalPages: 1}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, i).Return(details, &gitlab.Response{TotalItems: 1, TotalPages: 1}, nil).Once()
+	}
+}
+
+func runnerDetailsWithPaths(id int, groupWebURL string, projectPath string) *gitlab.RunnerDetails {
+	details := &gitlab.RunnerDetails{ID: id}
+	if groupWebURL != "" {
+		details.Groups = append(details.Groups, struct {
+			ID     int    "json:\"id\""
+			Name   string "json:\"name\""
+			WebURL string "json:\"web_url\""
+		}{ID: 100 + id, Name: fmt.Sprintf("Group%d", id), WebURL: groupWebURL})
+	}
+	if projectPath != "" {
+		details.Projects = append(details.Projects, struct {
+			ID                int    "json:\"id\""
+			Name              string "json:\"name\""
+			NameWithNamespace string "json:\"name_with_namespace\""
+			Path              string "json:\"path\""
+			PathWithNamespace string "json:\"path_with_namespace\""
+		}{ID: 200 + id, Name: fmt.Sprintf("Project%d", id), PathWithNamespace: projectPath})
+	}
+	return details
+}
+
+func mockGetRunnerDetailsWithTags(mock *mocks.GitLabClient, tags ...[]string) {
+	for i, tagList := range tags {
+		details := &gitlab.RunnerDetails{ID: i + 1, TagList: tagList}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, i+1).Return(details, &gitlab.Response{}, nil).Once()
 	}
 }
 
 func mockListRunners(mock *mocks.GitLabClient, numOfCalls int, errorAt ...int) {
+	mockListRunnersWithState(mock, defaultRunnerState, numOfCalls, errorAt...)
+}
+
+func mockListRunnersWithState(mock *mocks.GitLabClient, state string, numOfCalls int, errorAt ...int) {
 	for i := 1; i <= numOfCalls; i++ {
-		opts := &gitlab.ListRunnersOptions{
-			ListOptions: gitlab.ListOptions{
-				PerPage: 100,
-				Page:    i,
-			},
-			Status: gitlab.Ptr(runnerState),
-		}
+		opts := listRunnersOptions(state, nil)
+		opts.Page = i
 		baseId := 10 * i
 		rners := []*gitlab.Runner{}
 		for j := 0; j < 10; j++ {
@@ -252,9 +852,9 @@ func mockListRunners(mock *mocks.GitLabClient, numOfCalls int, errorAt ...int) {
 			TotalPages:   numOfCalls,
 		}
 		if shouldBeError(errorAt, i) {
-			mock.EXPECT().ListRunners(opts).Return(nil, resp, fmt.Errorf("Something went wrong %d", i)).Once()
+			mock.EXPECT().ListRunners(testifyMock.Anything, opts).Return(nil, resp, fmt.Errorf("Something went wrong %d", i)).Once()
 		} else {
-			mock.EXPECT().ListRunners(opts).Return(rners, resp, nil).Once()
+			mock.EXPECT().ListRunners(testifyMock.Anything, opts).Return(rners, resp, nil).Once()
 		}
 	}
 }
@@ -270,7 +870,7 @@ func shouldBeError(errorAt []int, pos int) bool {
 
 func mockDeleteRegisteredRunnerByID(mock *mocks.GitLabClient, numOfCalls int) {
 	for i := 1; i <= numOfCalls; i++ {
-		mock.EXPECT().DeleteRegisteredRunnerByID(i).Return(&gitlab.Response{Response: &http.Response{Status: "200 OK"}}, nil)
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, i).Return(&gitlab.Response{Response: &http.Response{Status: "200 OK"}}, nil)
 	}
 }
 
@@ -1,12 +1,22 @@
 package internal
 
-import gitlab "gitlab.com/gitlab-org/api/client-go"
+import (
+	"context"
 
-type responseWrapper struct {
-	resp gitlab.Response
-	err  error
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type detailsResultWrapper struct {
+	details *gitlab.RunnerDetails
+	err     error
 }
 
+type listRunnersFunc func(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error)
+
+// verifyFunc returns the reason why the runner must not be deleted anymore or
+// an empty string if it can be deleted.
+type verifyFunc func(ctx context.Context, rner *gitlab.RunnerDetails) string
+
 type listRunnerResultWrapper struct {
 	rners []*gitlab.Runner
 	err   error
@@ -15,4 +25,11 @@ type listRunnerResultWrapper struct {
 type abstractRunnerLocation struct {
 	id   int
 	name string
+	// path is the full path including all parent namespaces e.g. group/subgroup/project
+	path string
+}
+
+// retryCounter is implemented by clients which retry requests e.g. RetryClient.
+type retryCounter interface {
+	Retries(rid int) int
 }
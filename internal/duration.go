@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var dayUnits = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+
+// ParseDuration works like time.ParseDuration but additionally understands
+// days (d) and weeks (w) e.g. 30d, 2w or 1d12h.
+func ParseDuration(duration string) (time.Duration, error) {
+	var convErr error
+	hours := dayUnits.ReplaceAllStringFunc(duration, func(match string) string {
+		parts := dayUnits.FindStringSubmatch(match)
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			convErr = err
+			return match
+		}
+		if parts[2] == "w" {
+			value *= 7
+		}
+		return strconv.FormatFloat(value*24, 'f', -1, 64) + "h"
+	})
+	if convErr != nil {
+		return 0, convErr
+	}
+	return time.ParseDuration(hours)
+}
+
+// TimeAgo returns a human readable relative time e.g. 3 weeks ago.
+func TimeAgo(t time.Time) string {
+	since := time.Since(t)
+	units := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if count := int(since / unit.duration); count >= 1 {
+			if count == 1 {
+				return fmt.Sprintf("1 %s ago", unit.name)
+			}
+			return fmt.Sprintf("%d %ss ago", count, unit.name)
+		}
+	}
+	return "just now"
+}
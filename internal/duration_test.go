@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"72h":   72 * time.Hour,
+		"30d":   30 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1d12h": 36 * time.Hour,
+		"1.5d":  36 * time.Hour,
+		"90m":   90 * time.Minute,
+	}
+	for input, expected := range tests {
+		t.Run(input, func(t *testing.T) {
+			duration, err := ParseDuration(input)
+			require.NoError(t, err)
+			assert.Equal(t, expected, duration)
+		})
+	}
+
+	t.Run("Invalid duration", func(t *testing.T) {
+		_, err := ParseDuration("thirty days")
+		assert.Error(t, err)
+	})
+}
+
+func TestTimeAgo(t *testing.T) {
+	assert.Equal(t, "just now", TimeAgo(time.Now()))
+	assert.Equal(t, "1 minute ago", TimeAgo(time.Now().Add(-90*time.Second)))
+	assert.Equal(t, "5 hours ago", TimeAgo(time.Now().Add(-5*time.Hour)))
+	assert.Equal(t, "3 weeks ago", TimeAgo(time.Now().Add(-22*24*time.Hour)))
+	assert.Equal(t, "2 years ago", TimeAgo(time.Now().Add(-800*24*time.Hour)))
+}
@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+const (
+	regexFilterPrefix = "regex:"
+	globFilterPrefix  = "glob:"
+)
+
+var runnerTypes = []string{instanceRunnerType, "group_type", "project_type"}
+
+// ParseRunnerTypes validates the given runner types. Types can be given with
+// or without the _type suffix e.g. project or project_type.
+func ParseRunnerTypes(types []string) ([]string, error) {
+	parsed := []string{}
+	for _, typ := range types {
+		if !strings.HasSuffix(typ, "_type") {
+			typ += "_type"
+		}
+		if !contains(runnerTypes, typ) {
+			return nil, fmt.Errorf("unknown runner type %s, must be one of %s", typ, strings.Join(runnerTypes, ", "))
+		}
+		parsed = append(parsed, typ)
+	}
+	return parsed, nil
+}
+
+// ValidateFilters checks that all regex: and glob: filters can be compiled.
+func ValidateFilters(filters []string) error {
+	for _, filter := range filters {
+		if strings.HasPrefix(filter, regexFilterPrefix) {
+			if _, err := regexp.Compile(strings.TrimPrefix(filter, regexFilterPrefix)); err != nil {
+				return err
+			}
+		} else if strings.HasPrefix(filter, globFilterPrefix) {
+			if _, err := path.Match(strings.TrimPrefix(filter, globFilterPrefix), ""); err != nil {
+				return fmt.Errorf("invalid glob %s: %w", filter, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesFilter matches a filter against the given location. Filters prefixed
+// with regex: or glob: are matched against the name and full path. All other
+// filters must equal the name, id or full path. A full path also matches all
+// descendants, so excluding a parent group protects all of its subgroups and
+// projects.
+func matchesFilter(filter string, loc abstractRunnerLocation) bool {
+	if strings.HasPrefix(filter, regexFilterPrefix) {
+		rex := strings.TrimPrefix(filter, regexFilterPrefix)
+		matchesName, _ := regexp.MatchString(rex, loc.name)
+		matchesPath, _ := regexp.MatchString(rex, loc.path)
+		return matchesName || (loc.path != "" && matchesPath)
+	} else if strings.HasPrefix(filter, globFilterPrefix) {
+		glob := strings.TrimPrefix(filter, globFilterPrefix)
+		matchesName, _ := path.Match(glob, loc.name)
+		matchesPath, _ := path.Match(glob, loc.path)
+		return matchesName || matchesPath
+	}
+	if filter == loc.name || filter == strconv.Itoa(loc.id) {
+		return true
+	}
+	return loc.path != "" && (loc.path == filter || strings.HasPrefix(loc.path, strings.TrimSuffix(filter, "/")+"/"))
+}
+
+// groupPath extracts the full path of a group from its web url e.g.
+// https://gitlab.com/groups/platform/infra returns platform/infra.
+func groupPath(webURL string) string {
+	u, err := url.Parse(webURL)
+	if err != nil {
+		return ""
+	}
+	_, grpPath, found := strings.Cut(u.Path, "/groups/")
+	if !found {
+		return ""
+	}
+	return strings.Trim(grpPath, "/")
+}
+
+// ParseVersion parses a runner version. Missing minor or patch versions and a
+// leading v are tolerated e.g. v16 or 16.1.
+func ParseVersion(version string) (*semver.Version, error) {
+	v, err := semver.ParseTolerant(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %s: %w", version, err)
+	}
+	return &v, nil
+}
+
+// isSelectedVersion checks the runner version against MinVersion (inclusive)
+// and MaxVersion (exclusive). If a version filter is set runners with an
+// unknown version are not selected.
+func (c Clinar) isSelectedVersion(version string) bool {
+	if c.MinVersion == nil && c.MaxVersion == nil {
+		return true
+	}
+	v, err := ParseVersion(version)
+	if err != nil {
+		return false
+	}
+	if c.MinVersion != nil && v.LT(*c.MinVersion) {
+		return false
+	}
+	if c.MaxVersion != nil && v.GE(*c.MaxVersion) {
+		return false
+	}
+	return true
+}
+
+// isSelectedPlatform checks the runner platform and architecture against the
+// configured Platforms and Architectures ignoring the case.
+func (c Clinar) isSelectedPlatform(platform, architecture string) bool {
+	return containsFold(c.Platforms, platform) && containsFold(c.Architectures, architecture)
+}
+
+// containsFold returns true if values is empty or contains value ignoring the case.
+func containsFold(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelectedType returns true if no Types are configured or the runnerType is one of them.
+func (c Clinar) isSelectedType(runnerType string) bool {
+	return len(c.Types) == 0 || contains(c.Types, runnerType)
+}
+
+// filterTypes removes all runners which don't have one of the configured Types.
+func (c Clinar) filterTypes(rners []*gitlab.Runner) []*gitlab.Runner {
+	filtered := []*gitlab.Runner{}
+	for _, rner := range rners {
+		if c.isSelectedType(rner.RunnerType) {
+			filtered = append(filtered, rner)
+		}
+	}
+	return filtered
+}
+
+// isProtected returns true if the runner has one of the ProtectTags. Protected
+// runners are never selected.
+func (c Clinar) isProtected(tags []string) bool {
+	for _, tag := range c.ProtectTags {
+		if contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Clinar) isExcludedByTags(tags []string) bool {
+	return len(c.ExcludeTags) > 0 && c.matchesTags(c.ExcludeTags, tags)
+}
+
+func (c Clinar) isIncludedByTags(tags []string) bool {
+	return len(c.IncludeTags) == 0 || c.matchesTags(c.IncludeTags, tags)
+}
+
+// matchesTags returns true if the runner tags contain all of the filter tags if
+// TagMatch is set to all, otherwise if they contain any of them.
+func (c Clinar) matchesTags(filter []string, tags []string) bool {
+	for _, tag := range filter {
+		if c.TagMatch == TagMatchAll && !contains(tags, tag) {
+			return false
+		} else if c.TagMatch != TagMatchAll && contains(tags, tag) {
+			return true
+		}
+	}
+	return c.TagMatch == TagMatchAll
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRunnerTypes(t *testing.T) {
+	t.Run("With and without suffix", func(t *testing.T) {
+		types, err := ParseRunnerTypes([]string{"project", "group_type"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"project_type", "group_type"}, types)
+	})
+
+	t.Run("Unknown type", func(t *testing.T) {
+		types, err := ParseRunnerTypes([]string{"shared"})
+		assert.Nil(t, types)
+		assert.EqualError(t, err, "unknown runner type shared_type, must be one of instance_type, group_type, project_type")
+	})
+}
+
+func TestMatchesFilter(t *testing.T) {
+	loc := abstractRunnerLocation{id: 42, name: "team-a-prod"}
+
+	tests := map[string]bool{
+		"team-a-prod":          true,
+		"42":                   true,
+		"team-b-prod":          false,
+		"glob:team-*-prod":     true,
+		"glob:team-*-dev":      false,
+		"regex:^team-.*-prod$": true,
+		"regex:^prod":          false,
+	}
+	for filter, expected := range tests {
+		t.Run(filter, func(t *testing.T) {
+			assert.Equal(t, expected, matchesFilter(filter, loc))
+		})
+	}
+}
+
+func TestMatchesFilterByPath(t *testing.T) {
+	loc := abstractRunnerLocation{id: 42, name: "infra", path: "platform/team-a/infra"}
+
+	tests := map[string]bool{
+		"platform/team-a/infra":   true,
+		"platform":                true,
+		"platform/":               true,
+		"platform/team-a":         true,
+		"platform/team-b":         false,
+		"plat":                    false,
+		"glob:platform/*/infra":   true,
+		"glob:platform/*":         false,
+		"regex:^platform/.*":      true,
+		"regex:^other/.*":         false,
+		"infra":                   true,
+		"platform/team-a/infra/x": false,
+	}
+	for filter, expected := range tests {
+		t.Run(filter, func(t *testing.T) {
+			assert.Equal(t, expected, matchesFilter(filter, loc))
+		})
+	}
+}
+
+func TestGroupPath(t *testing.T) {
+	assert.Equal(t, "platform/infra", groupPath("https://gitlab.com/groups/platform/infra"))
+	assert.Equal(t, "platform", groupPath("https://example.com/gitlab/groups/platform/"))
+	assert.Equal(t, "", groupPath("https://gitlab.com/platform"))
+	assert.Equal(t, "", groupPath(""))
+}
+
+func TestValidateFilters(t *testing.T) {
+	assert.NoError(t, ValidateFilters([]string{"team-a-prod", "42", "glob:team-*", "regex:^team"}))
+	assert.Error(t, ValidateFilters([]string{"regex:team-("}))
+	assert.Error(t, ValidateFilters([]string{"glob:team-["}))
+}
+
+func TestIsSelectedVersion(t *testing.T) {
+	minVersion, err := ParseVersion("15.0")
+	require.NoError(t, err)
+	maxVersion, err := ParseVersion("v16")
+	require.NoError(t, err)
+	clinar := Clinar{MinVersion: minVersion, MaxVersion: maxVersion}
+
+	tests := map[string]bool{
+		"14.10.1": false,
+		"15.0.0":  true,
+		"15.11.2": true,
+		"16.0.0":  false,
+		"16.1.0":  false,
+		"":        false,
+		"unknown": false,
+	}
+	for version, expected := range tests {
+		t.Run(version, func(t *testing.T) {
+			assert.Equal(t, expected, clinar.isSelectedVersion(version))
+		})
+	}
+
+	t.Run("No version filter", func(t *testing.T) {
+		assert.True(t, Clinar{}.isSelectedVersion(""))
+	})
+
+	t.Run("Invalid version", func(t *testing.T) {
+		_, err := ParseVersion("latest")
+		assert.Error(t, err)
+	})
+}
+
+func TestIsSelectedPlatform(t *testing.T) {
+	clinar := Clinar{Platforms: []string{"linux"}, Architectures: []string{"amd64", "arm64"}}
+	assert.True(t, clinar.isSelectedPlatform("linux", "amd64"))
+	assert.True(t, clinar.isSelectedPlatform("Linux", "ARM64"))
+	assert.False(t, clinar.isSelectedPlatform("windows", "amd64"))
+	assert.False(t, clinar.isSelectedPlatform("linux", "s390x"))
+	assert.True(t, Clinar{}.isSelectedPlatform("windows", "386"))
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"context"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// gitLabServices combines the services of a *gitlab.Client which are used by
+// Clinar. The context of each call is passed on with gitlab.WithContext.
+type gitLabServices struct {
+	runners gitlab.RunnersServiceInterface
+	users   gitlab.UsersServiceInterface
+	groups  gitlab.GroupsServiceInterface
+}
+
+// NewGitLabClient returns a GitLabClient which is backed by the given *gitlab.Client.
+func NewGitLabClient(client *gitlab.Client) GitLabClient {
+	return gitLabServices{
+		runners: client.Runners,
+		users:   client.Users,
+		groups:  client.Groups,
+	}
+}
+
+func (s gitLabServices) GetRunnerDetails(ctx context.Context, rid interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
+	return s.runners.GetRunnerDetails(rid, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) ListRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return s.runners.ListRunners(opt, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) ListAllRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return s.runners.ListAllRunners(opt, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) ListGroupsRunners(ctx context.Context, gid interface{}, opt *gitlab.ListGroupsRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return s.runners.ListGroupsRunners(gid, opt, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) ListProjectRunners(ctx context.Context, pid interface{}, opt *gitlab.ListProjectRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return s.runners.ListProjectRunners(pid, opt, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) ListDescendantGroups(ctx context.Context, gid interface{}, opt *gitlab.ListDescendantGroupsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error) {
+	return s.groups.ListDescendantGroups(gid, opt, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) CurrentUser(ctx context.Context, options ...gitlab.RequestOptionFunc) (*gitlab.User, *gitlab.Response, error) {
+	return s.users.CurrentUser(withContext(ctx, options)...)
+}
+
+func (s gitLabServices) DeleteRegisteredRunnerByID(ctx context.Context, rid int, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	return s.runners.DeleteRegisteredRunnerByID(rid, withContext(ctx, options)...)
+}
+
+func (s gitLabServices) UpdateRunnerDetails(ctx context.Context, rid interface{}, opt *gitlab.UpdateRunnerDetailsOptions, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
+	return s.runners.UpdateRunnerDetails(rid, opt, withContext(ctx, options)...)
+}
+
+func withContext(ctx context.Context, options []gitlab.RequestOptionFunc) []gitlab.RequestOptionFunc {
+	return append([]gitlab.RequestOptionFunc{gitlab.WithContext(ctx)}, options...)
+}
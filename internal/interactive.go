@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// ErrAborted is returned by SelectRunners if the user aborted the selection.
+var ErrAborted = errors.New("selection aborted")
+
+const selectionHelp = `Toggle runners by number (e.g. 1 3-5), a = all, n = none, d <number> = details, y = confirm, q = abort`
+
+// SelectRunners shows the given runners as a checklist and lets the user toggle
+// them. Initially all runners are checked. The checked runners are returned
+// after the user confirmed the selection.
+func SelectRunners(in io.Reader, out io.Writer, runners []*gitlab.RunnerDetails) ([]*gitlab.RunnerDetails, error) {
+	checked := make([]bool, len(runners))
+	for i := range checked {
+		checked[i] = true
+	}
+
+	scanner := bufio.NewScanner(in)
+	printChecklist(out, runners, checked)
+	for {
+		fmt.Fprintf(out, "%s\n> ", selectionHelp)
+		if !scanner.Scan() {
+			return nil, ErrAborted
+		}
+		input := strings.TrimSpace(scanner.Text())
+		switch {
+		case input == "y":
+			selected := []*gitlab.RunnerDetails{}
+			for i, rner := range runners {
+				if checked[i] {
+					selected = append(selected, rner)
+				}
+			}
+			return selected, nil
+		case input == "q":
+			return nil, ErrAborted
+		case input == "a" || input == "n":
+			for i := range checked {
+				checked[i] = input == "a"
+			}
+		case strings.HasPrefix(input, "d "):
+			idx, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(input, "d ")))
+			if err != nil || idx < 1 || idx > len(runners) {
+				fmt.Fprintf(out, "Invalid runner number %s\n", strings.TrimPrefix(input, "d "))
+			} else {
+				printRunnerDetails(out, runners[idx-1])
+			}
+			continue
+		default:
+			if err := toggle(checked, input); err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+		}
+		printChecklist(out, runners, checked)
+	}
+}
+
+// toggle toggles all runners given by numbers or ranges e.g. 1 3-5.
+func toggle(checked []bool, input string) error {
+	indexes := []int{}
+	for _, field := range strings.Fields(input) {
+		from, to, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			return fmt.Errorf("invalid input %s", field)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				return fmt.Errorf("invalid input %s", field)
+			}
+		}
+		if start < 1 || end > len(checked) || start > end {
+			return fmt.Errorf("invalid runner number %s", field)
+		}
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+	for _, i := range indexes {
+		checked[i] = !checked[i]
+	}
+	return nil
+}
+
+func printChecklist(out io.Writer, runners []*gitlab.RunnerDetails, checked []bool) {
+	fmt.Fprintln(out)
+	for i, rner := range runners {
+		mark := " "
+		if checked[i] {
+			mark = "x"
+		}
+		fmt.Fprintf(out, "[%s] %3d) %d - %s - %s - last contact %s\n", mark, i+1, rner.ID, rner.RunnerType, rner.Description, lastContact(rner))
+	}
+}
+
+func printRunnerDetails(out io.Writer, rner *gitlab.RunnerDetails) {
+	groups := []string{}
+	for _, grp := range rner.Groups {
+		groups = append(groups, fmt.Sprintf("%s (%d)", grp.Name, grp.ID))
+	}
+	projects := []string{}
+	for _, proj := range rner.Projects {
+		projects = append(projects, fmt.Sprintf("%s (%d)", proj.PathWithNamespace, proj.ID))
+	}
+	fmt.Fprintf(out, "\nID:           %d\n", rner.ID)
+	fmt.Fprintf(out, "Description:  %s\n", rner.Description)
+	fmt.Fprintf(out, "Type:         %s\n", rner.RunnerType)
+	fmt.Fprintf(out, "Status:       %s\n", rner.Status)
+	fmt.Fprintf(out, "Last contact: %s\n", lastContact(rner))
+	fmt.Fprintf(out, "Tags:         %s\n", strings.Join(rner.TagList, ", "))
+	fmt.Fprintf(out, "Groups:       %s\n", strings.Join(groups, ", "))
+	fmt.Fprintf(out, "Projects:     %s\n", strings.Join(projects, ", "))
+	fmt.Fprintf(out, "Version:      %s %s/%s\n\n", rner.Version, rner.Platform, rner.Architecture)
+}
+
+func lastContact(rner *gitlab.RunnerDetails) string {
+	if rner.ContactedAt == nil {
+		return "never"
+	}
+	return TimeAgo(*rner.ContactedAt)
+}
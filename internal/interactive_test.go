@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestSelectRunners(t *testing.T) {
+	runners := []*gitlab.RunnerDetails{
+		{ID: 11, Description: "first"},
+		{ID: 12, Description: "second", ContactedAt: gitlab.Ptr(time.Now().Add(-22 * 24 * time.Hour))},
+		{ID: 13, Description: "third", TagList: []string{"docker", "gpu"}},
+		{ID: 14, Description: "fourth"},
+	}
+
+	t.Run("Confirm all", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		selected, err := SelectRunners(strings.NewReader("y\n"), out, runners)
+		require.NoError(t, err)
+		assert.Len(t, selected, 4)
+		assert.Contains(t, out.String(), "[x]   2) 12 -  - second - last contact 3 weeks ago")
+		assert.Contains(t, out.String(), "[x]   1) 11 -  - first - last contact never")
+	})
+
+	t.Run("Toggle runners", func(t *testing.T) {
+		selected, err := SelectRunners(strings.NewReader("1 3-4\n4\ny\n"), &bytes.Buffer{}, runners)
+		require.NoError(t, err)
+		require.Len(t, selected, 2)
+		assert.Equal(t, 12, selected[0].ID)
+		assert.Equal(t, 14, selected[1].ID)
+	})
+
+	t.Run("None and details", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		selected, err := SelectRunners(strings.NewReader("n\nd 3\n3\ny\n"), out, runners)
+		require.NoError(t, err)
+		require.Len(t, selected, 1)
+		assert.Equal(t, 13, selected[0].ID)
+		assert.Contains(t, out.String(), "Tags:         docker, gpu")
+	})
+
+	t.Run("Invalid input", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		selected, err := SelectRunners(strings.NewReader("5\nfoo\nd 9\ny\n"), out, runners)
+		require.NoError(t, err)
+		assert.Len(t, selected, 4)
+		assert.Contains(t, out.String(), "invalid runner number 5")
+		assert.Contains(t, out.String(), "invalid input foo")
+		assert.Contains(t, out.String(), "Invalid runner number 9")
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		selected, err := SelectRunners(strings.NewReader("q\n"), &bytes.Buffer{}, runners)
+		assert.ErrorIs(t, err, ErrAborted)
+		assert.Nil(t, selected)
+	})
+
+	t.Run("End of input", func(t *testing.T) {
+		selected, err := SelectRunners(strings.NewReader("1\n"), &bytes.Buffer{}, runners)
+		assert.ErrorIs(t, err, ErrAborted)
+		assert.Nil(t, selected)
+	})
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputCSV   = "csv"
+	OutputTable = "table"
+)
+
+// OutputFormats contains all formats supported by WriteRunners.
+var OutputFormats = []string{OutputText, OutputJSON, OutputYAML, OutputCSV, OutputTable}
+
+var csvHeader = []string{"id", "description", "type", "status", "online", "paused", "contacted_at", "tags", "groups", "projects", "version", "platform", "architecture"}
+
+// WriteRunners writes the given runners in the given format. JSON and YAML
+// contain the full runner details without tokens.
+func WriteRunners(w io.Writer, format string, runners []*gitlab.RunnerDetails) error {
+	switch format {
+	case OutputText, "":
+		writeText(w, runners)
+		return nil
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(withoutTokens(runners))
+	case OutputYAML:
+		return writeYAML(w, withoutTokens(runners))
+	case OutputCSV:
+		return writeCSV(w, runners)
+	case OutputTable:
+		return writeTable(w, runners)
+	}
+	return fmt.Errorf("unknown output format %s, must be one of %s", format, strings.Join(OutputFormats, ", "))
+}
+
+func writeText(w io.Writer, runners []*gitlab.RunnerDetails) {
+	if len(runners) == 0 {
+		fmt.Fprintln(w, "No stale runners found!")
+		return
+	}
+	fmt.Fprintln(w)
+	for _, rner := range runners {
+		groups := []string{}
+		for _, grp := range rner.Groups {
+			groups = append(groups, grp.Name)
+		}
+		projects := []string{}
+		for _, proj := range rner.Projects {
+			projects = append(projects, proj.Name)
+		}
+		fmt.Fprintf(w, "%d - %s - %s - %t - %s - %s - %s - %s/%s\n", rner.ID, rner.RunnerType, rner.Description, rner.Online, groups, projects, rner.Version, rner.Platform, rner.Architecture)
+	}
+}
+
+// writeYAML converts the value to JSON first so YAML uses the same keys.
+func writeYAML(w io.Writer, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(content, &generic); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(generic); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func writeCSV(w io.Writer, runners []*gitlab.RunnerDetails) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, rner := range runners {
+		contactedAt := ""
+		if rner.ContactedAt != nil {
+			contactedAt = rner.ContactedAt.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			strconv.Itoa(rner.ID),
+			rner.Description,
+			rner.RunnerType,
+			rner.Status,
+			strconv.FormatBool(rner.Online),
+			strconv.FormatBool(rner.Paused),
+			contactedAt,
+			strings.Join(rner.TagList, ";"),
+			strings.Join(groupPaths(rner), ";"),
+			strings.Join(projectPaths(rner), ";"),
+			rner.Version,
+			rner.Platform,
+			rner.Architecture,
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeTable(w io.Writer, runners []*gitlab.RunnerDetails) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTYPE\tDESCRIPTION\tSTATUS\tLAST CONTACT\tTAGS\tGROUPS\tPROJECTS\tVERSION")
+	for _, rner := range runners {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", rner.ID, rner.RunnerType, rner.Description, rner.Status, lastContact(rner),
+			strings.Join(rner.TagList, ","), strings.Join(groupPaths(rner), ","), strings.Join(projectPaths(rner), ","), rner.Version)
+	}
+	return tw.Flush()
+}
+
+// groupPaths returns the full paths of all groups of the runner. If the path
+// can't be determined the name is used.
+func groupPaths(rner *gitlab.RunnerDetails) []string {
+	paths := []string{}
+	for _, grp := range rner.Groups {
+		if grpPath := groupPath(grp.WebURL); grpPath != "" {
+			paths = append(paths, grpPath)
+		} else {
+			paths = append(paths, grp.Name)
+		}
+	}
+	return paths
+}
+
+func projectPaths(rner *gitlab.RunnerDetails) []string {
+	paths := []string{}
+	for _, proj := range rner.Projects {
+		if proj.PathWithNamespace != "" {
+			paths = append(paths, proj.PathWithNamespace)
+		} else {
+			paths = append(paths, proj.Name)
+		}
+	}
+	return paths
+}
+
+func withoutTokens(runners []*gitlab.RunnerDetails) []*gitlab.RunnerDetails {
+	cleaned := []*gitlab.RunnerDetails{}
+	for _, rner := range runners {
+		withoutToken := *rner
+		withoutToken.Token = ""
+		cleaned = append(cleaned, &withoutToken)
+	}
+	return cleaned
+}
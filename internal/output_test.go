@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.yaml.in/yaml/v3"
+)
+
+func TestWriteRunners(t *testing.T) {
+	contactedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rner := runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform/team-a", "platform/infra")
+	rner.Description = "some runner"
+	rner.RunnerType = "project_type"
+	rner.Status = "offline"
+	rner.Token = "secret"
+	rner.TagList = []string{"docker", "gpu"}
+	rner.ContactedAt = &contactedAt
+	rner.Version = "16.1.0"
+	runners := []*gitlab.RunnerDetails{rner}
+
+	t.Run("Text", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteRunners(out, OutputText, runners))
+		assert.Equal(t, "\n1 - project_type - some runner - false - [Group1] - [Project1] - 16.1.0 - /\n", out.String())
+	})
+
+	t.Run("Text without runners", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteRunners(out, OutputText, []*gitlab.RunnerDetails{}))
+		assert.Equal(t, "No stale runners found!\n", out.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteRunners(out, OutputJSON, runners))
+		read := []*gitlab.RunnerDetails{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &read))
+		require.Len(t, read, 1)
+		assert.Equal(t, "", read[0].Token)
+		assert.Equal(t, []string{"docker", "gpu"}, read[0].TagList)
+		assert.Equal(t, "platform/infra", read[0].Projects[0].PathWithNamespace)
+		assert.True(t, contactedAt.Equal(*read[0].ContactedAt))
+		assert.Equal(t, "secret", rner.Token)
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteRunners(out, OutputYAML, runners))
+		read := []map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal(out.Bytes(), &read))
+		require.Len(t, read, 1)
+		assert.Equal(t, "offline", read[0]["status"])
+		assert.Equal(t, "2024-01-02T03:04:05Z", read[0]["contacted_at"])
+		assert.Equal(t, "", read[0]["token"])
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteRunners(out, OutputCSV, runners))
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Equal(t, "id,description,type,status,online,paused,contacted_at,tags,groups,projects,version,platform,architecture", lines[0])
+		assert.Equal(t, "1,some runner,project_type,offline,false,false,2024-01-02T03:04:05Z,docker;gpu,platform/team-a,platform/infra,16.1.0,,", lines[1])
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteRunners(out, OutputTable, runners))
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.True(t, strings.HasPrefix(lines[0], "ID  TYPE"))
+		assert.Contains(t, lines[1], "docker,gpu")
+		assert.Contains(t, lines[1], "platform/team-a")
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		err := WriteRunners(&bytes.Buffer{}, "xml", runners)
+		assert.EqualError(t, err, "unknown output format xml, must be one of text, json, yaml, csv, table")
+	})
+}
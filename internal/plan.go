@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Plan contains the runners which were selected for deletion. The Hash is
+// calculated over all other fields to detect modifications of the plan.
+type Plan struct {
+	Host         string                  `json:"host"`
+	CreatedAt    time.Time               `json:"created_at"`
+	TotalRunners int                     `json:"total_runners"`
+	Runners      []*gitlab.RunnerDetails `json:"runners"`
+	Hash         string                  `json:"hash"`
+}
+
+// NewPlan creates a plan for the given runners. totalRunners is the number of
+// runners the selection was made from. Runner tokens are never written to a plan.
+func NewPlan(host string, totalRunners int, runners []*gitlab.RunnerDetails) (*Plan, error) {
+	plan := &Plan{
+		Host:         host,
+		CreatedAt:    time.Now().UTC(),
+		TotalRunners: totalRunners,
+		Runners:      withoutTokens(runners),
+	}
+	hash, err := plan.hash()
+	if err != nil {
+		return nil, err
+	}
+	plan.Hash = hash
+	return plan, nil
+}
+
+// ReadPlan reads a plan and verifies its hash.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	plan := &Plan{}
+	if err := json.NewDecoder(r).Decode(plan); err != nil {
+		return nil, err
+	}
+	hash, err := plan.hash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != plan.Hash {
+		return nil, fmt.Errorf("plan hash %s doesn't match the calculated hash %s, the plan was modified", plan.Hash, hash)
+	}
+	return plan, nil
+}
+
+// Write writes the plan as indented JSON.
+func (p *Plan) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+func (p Plan) hash() (string, error) {
+	p.Hash = ""
+	content, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyPlan fetches the current details of all runners of the plan and
+// returns only those which didn't change since the plan was created. The
+// changed runners are returned as skipped results.
+func (c *Clinar) VerifyPlan(ctx context.Context, plan *Plan) ([]*gitlab.RunnerDetails, []DeletionResult) {
+	unchanged := []*gitlab.RunnerDetails{}
+	skipped := []DeletionResult{}
+	for _, planned := range plan.Runners {
+		if ctx.Err() != nil {
+			skipped = append(skipped, DeletionResult{ID: planned.ID, Description: planned.Description, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"})
+			continue
+		}
+		current, _, err := c.Client.GetRunnerDetails(ctx, planned.ID)
+		if err != nil {
+			c.Logger.Errorf("Error %s getting runner details for runner ID %d", err, planned.ID)
+			skipped = append(skipped, DeletionResult{ID: planned.ID, Description: planned.Description, Outcome: OutcomeSkipped, Reason: fmt.Sprintf("error %s getting current runner details", err)})
+		} else if reason := runnerChanged(planned, current); reason != "" {
+			c.Logger.Warnf("Skipping %d, %s since the plan was created", planned.ID, reason)
+			skipped = append(skipped, DeletionResult{ID: planned.ID, Description: planned.Description, Outcome: OutcomeSkipped, Reason: reason + " since the plan was created"})
+		} else {
+			unchanged = append(unchanged, current)
+		}
+	}
+	return unchanged, skipped
+}
+
+// ApplyPlan deletes all runners of the plan which didn't change since the plan
+// was created. The selection flags aren't checked again, the runners were
+// selected when the plan was created. Only runners protected by tag are skipped
+// in addition. The returned Report also contains the changed runners as skipped.
+func (c *Clinar) ApplyPlan(ctx context.Context, plan *Plan) (*Report, error) {
+	planned := map[int]*gitlab.RunnerDetails{}
+	for _, rner := range plan.Runners {
+		planned[rner.ID] = rner
+	}
+	unchanged, skipped := c.VerifyPlan(ctx, plan)
+	report, err := c.cleanupRunners(ctx, unchanged, c.reverifyPlanned(planned))
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range skipped {
+		c.record(report, planned[result.ID], result)
+	}
+	if ctx.Err() != nil {
+		report.Interrupted = true
+	}
+	return report, nil
+}
+
+// reverifyPlanned returns a verifyFunc which fetches the current details of a
+// runner and compares them with the planned runner.
+func (c *Clinar) reverifyPlanned(planned map[int]*gitlab.RunnerDetails) verifyFunc {
+	return func(ctx context.Context, rner *gitlab.RunnerDetails) string {
+		current, _, err := c.Client.GetRunnerDetails(ctx, rner.ID)
+		if err != nil {
+			return fmt.Sprintf("error %s getting current runner details", err)
+		}
+		if reason := runnerChanged(planned[rner.ID], current); reason != "" {
+			return reason + " since the plan was created"
+		}
+		if c.isProtected(current.TagList) {
+			return "runner is protected by tag"
+		}
+		return ""
+	}
+}
+
+// runnerChanged returns the reason why the current runner doesn't match the
+// planned one or an empty string if it is unchanged. The last contact is only
+// compared for runners which were offline, online runners e.g. selected as
+// paused contact GitLab all the time.
+func runnerChanged(planned, current *gitlab.RunnerDetails) string {
+	if !planned.Online && current.Online {
+		return "runner came back online"
+	}
+	if !planned.Online && current.ContactedAt != nil && (planned.ContactedAt == nil || current.ContactedAt.After(*planned.ContactedAt)) {
+		return "runner contacted GitLab"
+	}
+	if !sameIDs(groupIDs(planned), groupIDs(current)) {
+		return "groups changed"
+	}
+	if !sameIDs(projectIDs(planned), projectIDs(current)) {
+		return "projects changed"
+	}
+	return ""
+}
+
+func groupIDs(details *gitlab.RunnerDetails) map[int]bool {
+	ids := map[int]bool{}
+	for _, grp := range details.Groups {
+		ids[grp.ID] = true
+	}
+	return ids
+}
+
+func projectIDs(details *gitlab.RunnerDetails) map[int]bool {
+	ids := map[int]bool{}
+	for _, proj := range details.Projects {
+		ids[proj.ID] = true
+	}
+	return ids
+}
+
+func sameIDs(a, b map[int]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id := range a {
+		if !b[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestPlan(t *testing.T) {
+	contactedAt, err := time.Parse(time.RFC3339, "2024-01-02T03:04:05.123+02:00")
+	require.NoError(t, err)
+	runners := []*gitlab.RunnerDetails{
+		runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform", ""),
+		{ID: 2, Token: "secret", ContactedAt: &contactedAt, TagList: []string{"docker"}},
+	}
+
+	t.Run("Write and read", func(t *testing.T) {
+		plan, err := NewPlan("https://gitlab.com", 10, runners)
+		require.NoError(t, err)
+		assert.NotEmpty(t, plan.Hash)
+		assert.Equal(t, "", plan.Runners[1].Token)
+		assert.Equal(t, "secret", runners[1].Token)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, plan.Write(buf))
+		read, err := ReadPlan(buf)
+		require.NoError(t, err)
+		assert.Equal(t, plan.Hash, read.Hash)
+		assert.Equal(t, "https://gitlab.com", read.Host)
+		assert.Equal(t, 10, read.TotalRunners)
+		require.Len(t, read.Runners, 2)
+		assert.Equal(t, 1, read.Runners[0].ID)
+		assert.Equal(t, "platform", groupPath(read.Runners[0].Groups[0].WebURL))
+	})
+
+	t.Run("Modified plan", func(t *testing.T) {
+		plan, err := NewPlan("https://gitlab.com", 10, runners)
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, plan.Write(buf))
+		modified := strings.Replace(buf.String(), `"id": 2`, `"id": 3`, 1)
+		_, err = ReadPlan(strings.NewReader(modified))
+		assert.ErrorContains(t, err, "the plan was modified")
+	})
+}
+
+func TestVerifyPlan(t *testing.T) {
+	logger, logHook := logrusTest.NewNullLogger()
+	lastContact := time.Now().Add(-48 * time.Hour)
+	planned := []*gitlab.RunnerDetails{
+		{ID: 1, ContactedAt: &lastContact},
+		{ID: 2, ContactedAt: &lastContact},
+		{ID: 3, ContactedAt: &lastContact},
+		runnerDetailsWithPaths(4, "", "platform/infra"),
+		{ID: 5},
+		{ID: 6},
+		{ID: 7, Online: true, Paused: true, ContactedAt: &lastContact},
+	}
+	plan, err := NewPlan("https://gitlab.com", 10, planned)
+	require.NoError(t, err)
+
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, ContactedAt: &lastContact}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Online: true, ContactedAt: gitlab.Ptr(time.Now())}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3, ContactedAt: gitlab.Ptr(time.Now())}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 4).Return(&gitlab.RunnerDetails{ID: 4}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 5).Return(&gitlab.RunnerDetails{ID: 5}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 6).Return(nil, &gitlab.Response{}, errors.New("404 Not Found")).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 7).Return(&gitlab.RunnerDetails{ID: 7, Online: true, Paused: true, ContactedAt: gitlab.Ptr(time.Now())}, &gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger}
+	unchanged, skipped := clinar.VerifyPlan(context.Background(), plan)
+	require.Len(t, unchanged, 3)
+	assert.Equal(t, 1, unchanged[0].ID)
+	assert.Equal(t, 5, unchanged[1].ID)
+	assert.Equal(t, 7, unchanged[2].ID)
+	require.Len(t, skipped, 4)
+	assert.Equal(t, DeletionResult{ID: 2, Outcome: OutcomeSkipped, Reason: "runner came back online since the plan was created"}, skipped[0])
+	assert.Equal(t, DeletionResult{ID: 6, Outcome: OutcomeSkipped, Reason: "error 404 Not Found getting current runner details"}, skipped[3])
+
+	messages := []string{}
+	for _, entry := range logHook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Contains(t, messages, "Skipping 2, runner came back online since the plan was created")
+	assert.Contains(t, messages, "Skipping 3, runner contacted GitLab since the plan was created")
+	assert.Contains(t, messages, "Skipping 4, projects changed since the plan was created")
+	mock.AssertExpectations(t)
+}
+
+func TestApplyPlan(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	plan, err := NewPlan("https://gitlab.com", 10, []*gitlab.RunnerDetails{{ID: 1}, {ID: 2}})
+	require.NoError(t, err)
+
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Twice()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Online: true}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(&gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger}
+	report, err := clinar.ApplyPlan(context.Background(), plan)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, ExitOK, report.ExitCode())
+	mock.AssertExpectations(t)
+}
+
+func TestApplyPlanPaused(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	lastContact := time.Now().Add(-time.Minute)
+	// Created with --status paused, applied with the default --status offline
+	plan, err := NewPlan("https://gitlab.com", 10, []*gitlab.RunnerDetails{
+		{ID: 1, Online: true, Paused: true, ContactedAt: &lastContact},
+		{ID: 2, Online: true, Paused: true, ContactedAt: &lastContact},
+	})
+	require.NoError(t, err)
+
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Online: true, Paused: true, ContactedAt: gitlab.Ptr(time.Now())}, &gitlab.Response{}, nil).Twice()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Online: true, Paused: true, TagList: []string{"clinar-keep"}}, &gitlab.Response{}, nil).Twice()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(&gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger, ProtectTags: []string{"clinar-keep"}}
+	report, err := clinar.ApplyPlan(context.Background(), plan)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Contains(t, report.Results, DeletionResult{ID: 2, Outcome: OutcomeSkipped, Reason: "runner is protected by tag"})
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// quarantineMarker is written into the maintenance note of quarantined runners
+// followed by the time the runner was quarantined and pausedMarker if the
+// runner was already paused before.
+const (
+	quarantineMarker = "clinar-quarantine: "
+	pausedMarker     = "paused=true"
+)
+
+// QuarantineRunners pauses all given runners which aren't quarantined yet and
+// deletes the runners which are quarantined for longer than the GracePeriod.
+// The limits are checked for the expired runners before any runner is paused.
+// The returned Report contains the quarantined and the deleted runners.
+func (c *Clinar) QuarantineRunners(ctx context.Context, staleRunners []*gitlab.RunnerDetails) (*Report, error) {
+	unquarantined := []*gitlab.RunnerDetails{}
+	expired := []*gitlab.RunnerDetails{}
+	for _, rner := range staleRunners {
+		since, quarantined := quarantinedSince(rner.MaintenanceNote)
+		if !quarantined {
+			unquarantined = append(unquarantined, rner)
+		} else if time.Since(since) > c.GracePeriod {
+			expired = append(expired, rner)
+		} else {
+			c.Logger.Infof("Runner %d - %s is quarantined since %s", rner.ID, rner.Name, since.Format(time.RFC3339))
+		}
+	}
+	if err := c.checkLimits(len(expired)); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Results: []DeletionResult{}}
+	for _, rner := range unquarantined {
+		if ctx.Err() != nil {
+			c.interrupted(report, rner)
+			continue
+		}
+		c.record(report, rner, c.quarantine(ctx, rner))
+	}
+
+	deleted, err := c.CleanupRunners(ctx, expired)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range deleted.Results {
+		report.add(result)
+	}
+	report.Interrupted = report.Interrupted || deleted.Interrupted
+	return report, nil
+}
+
+// ReleaseQuarantinedRunners un-pauses all quarantined runners which contacted
+// GitLab since they were quarantined.
+func (c *Clinar) ReleaseQuarantinedRunners(ctx context.Context) error {
+	sources, err := c.runnerSources(ctx)
+	if err != nil {
+		return err
+	}
+	for _, list := range sources {
+		rners, err := c.listRunners(ctx, list, listRunnersOptions(pausedRunnerState, c.Types))
+		if err != nil {
+			return err
+		}
+		for _, rner := range rners {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			details, _, err := c.Client.GetRunnerDetails(ctx, rner.ID)
+			if err != nil {
+				c.Logger.Errorf("Error %s getting runner details for runner ID %d", err, rner.ID)
+				continue
+			}
+			since, quarantined := quarantinedSince(details.MaintenanceNote)
+			if quarantined && (details.Online || (details.ContactedAt != nil && details.ContactedAt.After(since))) {
+				c.release(ctx, details)
+			}
+		}
+	}
+	return nil
+}
+
+// quarantine pauses the runner, writes the marker into its maintenance note and
+// returns the outcome.
+func (c *Clinar) quarantine(ctx context.Context, rner *gitlab.RunnerDetails) DeletionResult {
+	marker := quarantineMarker + time.Now().UTC().Format(time.RFC3339)
+	if rner.Paused {
+		marker += " " + pausedMarker
+	}
+	note := strings.TrimSpace(removeQuarantineMarker(rner.MaintenanceNote) + "\n" + marker)
+	_, resp, err := c.Client.UpdateRunnerDetails(ctx, rner.ID, &gitlab.UpdateRunnerDetailsOptions{
+		Paused:          gitlab.Ptr(true),
+		MaintenanceNote: gitlab.Ptr(note),
+	})
+	result := DeletionResult{ID: rner.ID, Description: rner.Description, Outcome: OutcomeQuarantined}
+	if resp != nil && resp.Response != nil {
+		result.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		c.Logger.Errorf("Error %s quarantining runner ID %d", err, rner.ID)
+		result.Outcome = OutcomeFailed
+		result.Error = err.Error()
+	} else {
+		c.Logger.Infof("Quarantined %d - %s", rner.ID, rner.Name)
+	}
+	return result
+}
+
+// release removes the marker from the maintenance note of the runner. The
+// runner is only un-paused if it wasn't paused before it was quarantined.
+func (c *Clinar) release(ctx context.Context, rner *gitlab.RunnerDetails) {
+	opts := &gitlab.UpdateRunnerDetailsOptions{
+		MaintenanceNote: gitlab.Ptr(removeQuarantineMarker(rner.MaintenanceNote)),
+	}
+	pausedBefore := pausedBeforeQuarantine(rner.MaintenanceNote)
+	if !pausedBefore {
+		opts.Paused = gitlab.Ptr(false)
+	}
+	_, _, err := c.Client.UpdateRunnerDetails(ctx, rner.ID, opts)
+	if err != nil {
+		c.Logger.Errorf("Error %s releasing runner ID %d from quarantine", err, rner.ID)
+	} else if pausedBefore {
+		c.Logger.Infof("Released %d - %s from quarantine, runner stays paused as it was paused before", rner.ID, rner.Name)
+	} else {
+		c.Logger.Infof("Released %d - %s from quarantine, runner is back online", rner.ID, rner.Name)
+	}
+}
+
+// quarantinedSince returns the time a runner was quarantined based on the
+// marker in its maintenance note.
+func quarantinedSince(note string) (time.Time, bool) {
+	fields := quarantineMarkerFields(note)
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	since, err := time.Parse(time.RFC3339, fields[0])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+// pausedBeforeQuarantine returns true if the runner was already paused when it
+// was quarantined.
+func pausedBeforeQuarantine(note string) bool {
+	for _, field := range quarantineMarkerFields(note) {
+		if field == pausedMarker {
+			return true
+		}
+	}
+	return false
+}
+
+func quarantineMarkerFields(note string) []string {
+	for _, line := range strings.Split(note, "\n") {
+		if strings.HasPrefix(line, quarantineMarker) {
+			return strings.Fields(strings.TrimPrefix(line, quarantineMarker))
+		}
+	}
+	return nil
+}
+
+func removeQuarantineMarker(note string) string {
+	lines := []string{}
+	for _, line := range strings.Split(note, "\n") {
+		if !strings.HasPrefix(line, quarantineMarker) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestQuarantineRunners(t *testing.T) {
+	logger, logHook := logrusTest.NewNullLogger()
+	longAgo := time.Now().Add(-10 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	recently := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().UpdateRunnerDetails(testifyMock.Anything, 1, testifyMock.MatchedBy(func(opts *gitlab.UpdateRunnerDetailsOptions) bool {
+		since, quarantined := quarantinedSince(*opts.MaintenanceNote)
+		return *opts.Paused && quarantined && time.Since(since) < time.Minute && removeQuarantineMarker(*opts.MaintenanceNote) == "some note" &&
+			!pausedBeforeQuarantine(*opts.MaintenanceNote)
+	})).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Once()
+	// Runner 6 was paused by someone before it was quarantined
+	mock.EXPECT().UpdateRunnerDetails(testifyMock.Anything, 6, testifyMock.MatchedBy(func(opts *gitlab.UpdateRunnerDetailsOptions) bool {
+		_, quarantined := quarantinedSince(*opts.MaintenanceNote)
+		return *opts.Paused && quarantined && pausedBeforeQuarantine(*opts.MaintenanceNote)
+	})).Return(&gitlab.RunnerDetails{ID: 6}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, MaintenanceNote: quarantineMarker + longAgo}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 2).Return(&gitlab.Response{Response: &http.Response{Status: "204 No Content"}}, nil).Once()
+	mock.EXPECT().UpdateRunnerDetails(testifyMock.Anything, 4, testifyMock.Anything).Return(nil, &gitlab.Response{}, errors.New("403 Forbidden")).Once()
+	// Runner 5 was released from quarantine after it was listed
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 5).Return(&gitlab.RunnerDetails{ID: 5}, &gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger, GracePeriod: 7 * 24 * time.Hour}
+	report, err := clinar.QuarantineRunners(context.Background(), []*gitlab.RunnerDetails{
+		{ID: 1, MaintenanceNote: "some note"},
+		{ID: 2, MaintenanceNote: quarantineMarker + longAgo},
+		{ID: 3, MaintenanceNote: "some note\n" + quarantineMarker + recently},
+		{ID: 4},
+		{ID: 5, MaintenanceNote: quarantineMarker + longAgo},
+		{ID: 6, Paused: true},
+	})
+	require.NoError(t, err)
+	mock.AssertExpectations(t)
+	assert.Equal(t, 2, report.Quarantined)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, ExitPartialFailure, report.ExitCode())
+	assert.Contains(t, report.Results, DeletionResult{ID: 4, Outcome: OutcomeFailed, Error: "403 Forbidden"})
+	assert.Contains(t, report.Results, DeletionResult{ID: 5, Outcome: OutcomeSkipped, Reason: "runner was released from quarantine"})
+
+	messages := []string{}
+	for _, entry := range logHook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Contains(t, messages, "Quarantined 1 - ")
+	assert.Contains(t, messages, "Runner 3 -  is quarantined since "+recently)
+	assert.Contains(t, messages, "Deleting 2 - ")
+}
+
+func TestQuarantineRunnersInterrupted(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	longAgo := time.Now().Add(-10 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().UpdateRunnerDetails(testifyMock.Anything, 1, testifyMock.Anything).Run(func(_ context.Context, rid interface{}, opt *gitlab.UpdateRunnerDetailsOptions, options ...gitlab.RequestOptionFunc) {
+		cancel()
+	}).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger, GracePeriod: 7 * 24 * time.Hour}
+	report, err := clinar.QuarantineRunners(ctx, []*gitlab.RunnerDetails{
+		{ID: 1},
+		{ID: 2},
+		{ID: 3, MaintenanceNote: quarantineMarker + longAgo},
+	})
+	require.NoError(t, err)
+	mock.AssertExpectations(t)
+	assert.True(t, report.Interrupted)
+	assert.Equal(t, []DeletionResult{
+		{ID: 1, Outcome: OutcomeQuarantined},
+		{ID: 2, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"},
+		{ID: 3, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"},
+	}, report.Results)
+	assert.Equal(t, ExitPartialFailure, report.ExitCode())
+}
+
+func TestQuarantineRunnersLimit(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	longAgo := time.Now().Add(-10 * 24 * time.Hour).UTC().Format(time.RFC3339)
+
+	mock := &mocks.GitLabClient{}
+	clinar := Clinar{Client: mock, Logger: logger, GracePeriod: 7 * 24 * time.Hour, MaxDelete: 1}
+	report, err := clinar.QuarantineRunners(context.Background(), []*gitlab.RunnerDetails{
+		{ID: 1},
+		{ID: 2, MaintenanceNote: quarantineMarker + longAgo},
+		{ID: 3, MaintenanceNote: quarantineMarker + longAgo},
+	})
+	assert.EqualError(t, err, "2 runners selected for deletion, which exceeds the limit of 1 runners")
+	assert.Nil(t, report)
+	// No runner is paused if the limit is exceeded
+	mock.AssertExpectations(t)
+	mock.AssertNotCalled(t, "UpdateRunnerDetails", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything)
+}
+
+func TestReleaseQuarantinedRunners(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	quarantinedAt := time.Now().Add(-24 * time.Hour).UTC()
+	note := "some note\n" + quarantineMarker + quarantinedAt.Format(time.RFC3339)
+
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().ListRunners(testifyMock.Anything, listRunnersOptions(pausedRunnerState, nil)).
+		Return([]*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, MaintenanceNote: note, Online: true, ContactedAt: gitlab.Ptr(time.Now())}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, MaintenanceNote: note, ContactedAt: gitlab.Ptr(quarantinedAt.Add(-time.Hour))}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3, Online: true}, &gitlab.Response{}, nil).Once()
+	// Runner 4 was paused by someone before it was quarantined and must stay paused
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 4).Return(&gitlab.RunnerDetails{ID: 4, MaintenanceNote: note + " " + pausedMarker, Online: true}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().UpdateRunnerDetails(testifyMock.Anything, 1, &gitlab.UpdateRunnerDetailsOptions{
+		Paused:          gitlab.Ptr(false),
+		MaintenanceNote: gitlab.Ptr("some note"),
+	}).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().UpdateRunnerDetails(testifyMock.Anything, 4, &gitlab.UpdateRunnerDetailsOptions{
+		MaintenanceNote: gitlab.Ptr("some note"),
+	}).Return(&gitlab.RunnerDetails{ID: 4}, &gitlab.Response{}, nil).Once()
+
+	clinar := Clinar{Client: mock, Logger: logger}
+	require.NoError(t, clinar.ReleaseQuarantinedRunners(context.Background()))
+	mock.AssertExpectations(t)
+}
+
+func TestQuarantineMarker(t *testing.T) {
+	since, quarantined := quarantinedSince("note\n" + quarantineMarker + "2024-01-02T03:04:05Z")
+	assert.True(t, quarantined)
+	assert.Equal(t, "2024-01-02T03:04:05Z", since.Format(time.RFC3339))
+
+	assert.False(t, pausedBeforeQuarantine("note\n"+quarantineMarker+"2024-01-02T03:04:05Z"))
+
+	since, quarantined = quarantinedSince("note\n" + quarantineMarker + "2024-01-02T03:04:05Z " + pausedMarker)
+	assert.True(t, quarantined)
+	assert.Equal(t, "2024-01-02T03:04:05Z", since.Format(time.RFC3339))
+	assert.True(t, pausedBeforeQuarantine("note\n"+quarantineMarker+"2024-01-02T03:04:05Z "+pausedMarker))
+
+	_, quarantined = quarantinedSince("note")
+	assert.False(t, quarantined)
+
+	assert.Equal(t, "note", removeQuarantineMarker("note\n"+quarantineMarker+"2024-01-02T03:04:05Z"))
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	OutcomeDeleted     = "deleted"
+	OutcomeQuarantined = "quarantined"
+	OutcomeSkipped     = "skipped"
+	OutcomeFailed      = "failed"
+)
+
+// Exit codes of a cleanup run. Fatal errors exit with 1.
+const (
+	ExitOK             = 0
+	ExitPartialFailure = 2
+	ExitNothingToDo    = 3
+)
+
+// DeletionResult is the outcome of deleting (or quarantining) a single runner.
+type DeletionResult struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	Outcome     string `json:"outcome"`
+	// StatusCode is the HTTP status of the delete (or quarantine) request. It is
+	// 0 if the runner was skipped or the request didn't get a response.
+	StatusCode int `json:"status_code,omitempty"`
+	// Reason is set if the runner was skipped.
+	Reason string `json:"reason,omitempty"`
+	// Error is set if the runner couldn't be deleted or quarantined.
+	Error string `json:"error,omitempty"`
+	// Retries is the number of retried requests for the runner.
+	Retries int `json:"retries,omitempty"`
+}
+
+// Report contains the results of all runners of a cleanup run.
+type Report struct {
+	Deleted     int `json:"deleted"`
+	Quarantined int `json:"quarantined"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+	Retries     int `json:"retries"`
+	// Interrupted is set if the run was interrupted before all runners were deleted.
+	Interrupted bool             `json:"interrupted"`
+	Results     []DeletionResult `json:"results"`
+}
+
+func (r *Report) add(result DeletionResult) {
+	switch result.Outcome {
+	case OutcomeDeleted:
+		r.Deleted++
+	case OutcomeQuarantined:
+		r.Quarantined++
+	case OutcomeSkipped:
+		r.Skipped++
+	case OutcomeFailed:
+		r.Failed++
+	}
+	r.Retries += result.Retries
+	r.Results = append(r.Results, result)
+}
+
+// ExitCode returns ExitPartialFailure if any deletion or quarantine failed or
+// the run was interrupted, ExitNothingToDo if no runner was deleted or
+// quarantined and ExitOK otherwise.
+func (r *Report) ExitCode() int {
+	if r.Failed > 0 || r.Interrupted {
+		return ExitPartialFailure
+	}
+	if r.Deleted == 0 && r.Quarantined == 0 {
+		return ExitNothingToDo
+	}
+	return ExitOK
+}
+
+// WriteReport writes the report as JSON or YAML if requested by format and as
+// a table otherwise.
+func WriteReport(w io.Writer, format string, report *Report) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case OutputYAML:
+		return writeYAML(w, report)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDESCRIPTION\tOUTCOME\tHTTP STATUS\tRETRIES\tDETAILS")
+	for _, result := range report.Results {
+		status := ""
+		if result.StatusCode != 0 {
+			status = fmt.Sprint(result.StatusCode)
+		}
+		details := result.Reason
+		if result.Error != "" {
+			details = result.Error
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n", result.ID, result.Description, result.Outcome, status, result.Retries, details)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	quarantined := ""
+	if report.Quarantined > 0 {
+		quarantined = fmt.Sprintf("%d quarantined, ", report.Quarantined)
+	}
+	if _, err := fmt.Fprintf(w, "\n%d deleted, %s%d skipped, %d failed, %d retries\n", report.Deleted, quarantined, report.Skipped, report.Failed, report.Retries); err != nil {
+		return err
+	}
+	if report.Interrupted {
+		_, err := fmt.Fprintln(w, "Interrupted, the skipped runners were not deleted")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportExitCode(t *testing.T) {
+	assert.Equal(t, ExitNothingToDo, (&Report{}).ExitCode())
+	assert.Equal(t, ExitNothingToDo, (&Report{Skipped: 2}).ExitCode())
+	assert.Equal(t, ExitOK, (&Report{Deleted: 1, Skipped: 2}).ExitCode())
+	assert.Equal(t, ExitPartialFailure, (&Report{Deleted: 1, Failed: 1}).ExitCode())
+	assert.Equal(t, ExitPartialFailure, (&Report{Failed: 1}).ExitCode())
+	assert.Equal(t, ExitOK, (&Report{Quarantined: 1}).ExitCode())
+	assert.Equal(t, ExitPartialFailure, (&Report{Quarantined: 1, Failed: 1}).ExitCode())
+}
+
+func TestWriteReport(t *testing.T) {
+	report := &Report{Results: []DeletionResult{}}
+	report.add(DeletionResult{ID: 1, Description: "first", Outcome: OutcomeDeleted, StatusCode: 204})
+	report.add(DeletionResult{ID: 2, Description: "second", Outcome: OutcomeSkipped, Reason: "runner is online again"})
+	report.add(DeletionResult{ID: 3, Description: "third", Outcome: OutcomeFailed, StatusCode: 503, Error: "503 Service Unavailable", Retries: 2})
+
+	t.Run("Table", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteReport(out, OutputText, report))
+		assert.Equal(t, `ID  DESCRIPTION  OUTCOME  HTTP STATUS  RETRIES  DETAILS
+1   first        deleted  204          0        
+2   second       skipped               0        runner is online again
+3   third        failed   503          2        503 Service Unavailable
+
+1 deleted, 1 skipped, 1 failed, 2 retries
+`, out.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteReport(out, OutputJSON, report))
+		assert.JSONEq(t, `{"deleted": 1, "quarantined": 0, "skipped": 1, "failed": 1, "retries": 2, "interrupted": false, "results": [
+			{"id": 1, "description": "first", "outcome": "deleted", "status_code": 204},
+			{"id": 2, "description": "second", "outcome": "skipped", "reason": "runner is online again"},
+			{"id": 3, "description": "third", "outcome": "failed", "status_code": 503, "error": "503 Service Unavailable", "retries": 2}
+		]}`, out.String())
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteReport(out, OutputYAML, &Report{Deleted: 1, Results: []DeletionResult{{ID: 1, Description: "first", Outcome: OutcomeDeleted}}}))
+		assert.Equal(t, `deleted: 1
+failed: 0
+interrupted: false
+quarantined: 0
+results:
+  - description: first
+    id: 1
+    outcome: deleted
+retries: 0
+skipped: 0
+`, out.String())
+	})
+
+	t.Run("Interrupted", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		interrupted := &Report{Skipped: 1, Interrupted: true, Results: []DeletionResult{{ID: 1, Outcome: OutcomeSkipped, Reason: "interrupted before deletion"}}}
+		require.NoError(t, WriteReport(out, OutputTable, interrupted))
+		assert.Contains(t, out.String(), "0 deleted, 1 skipped, 0 failed, 0 retries\nInterrupted, the skipped runners were not deleted\n")
+		assert.Equal(t, ExitPartialFailure, interrupted.ExitCode())
+	})
+
+	t.Run("Quarantined", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		quarantined := &Report{Results: []DeletionResult{}}
+		quarantined.add(DeletionResult{ID: 1, Outcome: OutcomeQuarantined, StatusCode: 200})
+		require.NoError(t, WriteReport(out, OutputTable, quarantined))
+		assert.Contains(t, out.String(), "0 deleted, 1 quarantined, 0 skipped, 0 failed, 0 retries\n")
+	})
+}
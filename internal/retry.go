@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = time.Minute
+)
+
+// RetryClient wraps a GitLabClient and retries requests which failed with a
+// 5xx or 429 status using exponential backoff with jitter. Retry-After and
+// RateLimit-Reset headers are honored.
+type RetryClient struct {
+	GitLabClient
+	Logger *logrus.Logger
+	// MaxAttempts is the number of attempts of a request including the first one.
+	MaxAttempts int
+	// Timeout is the overall time all attempts of a request may take. No retry
+	// is started if it would exceed the Timeout. 0 means no timeout.
+	Timeout   time.Duration
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	sleep   func(ctx context.Context, d time.Duration) error
+	mutex   sync.Mutex
+	retries map[int]int
+}
+
+// NewRetryClient returns a RetryClient which retries requests of client up to
+// maxAttempts times within timeout.
+func NewRetryClient(client GitLabClient, logger *logrus.Logger, maxAttempts int, timeout time.Duration) *RetryClient {
+	return &RetryClient{
+		GitLabClient: client,
+		Logger:       logger,
+		MaxAttempts:  maxAttempts,
+		Timeout:      timeout,
+		BaseDelay:    defaultRetryBaseDelay,
+		MaxDelay:     defaultRetryMaxDelay,
+		sleep:        sleep,
+		retries:      map[int]int{},
+	}
+}
+
+// Retries returns the number of retries of requests for the runner rid.
+func (r *RetryClient) Retries(rid int) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.retries[rid]
+}
+
+func (r *RetryClient) GetRunnerDetails(ctx context.Context, rid interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
+	return retry(ctx, r, fmt.Sprintf("getting runner details for runner ID %v", rid), rid, func() (*gitlab.RunnerDetails, *gitlab.Response, error) {
+		return r.GitLabClient.GetRunnerDetails(ctx, rid, options...)
+	})
+}
+
+func (r *RetryClient) ListRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return retry(ctx, r, "listing runners", nil, func() ([]*gitlab.Runner, *gitlab.Response, error) {
+		return r.GitLabClient.ListRunners(ctx, opt, options...)
+	})
+}
+
+func (r *RetryClient) ListAllRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return retry(ctx, r, "listing all runners", nil, func() ([]*gitlab.Runner, *gitlab.Response, error) {
+		return r.GitLabClient.ListAllRunners(ctx, opt, options...)
+	})
+}
+
+func (r *RetryClient) ListGroupsRunners(ctx context.Context, gid interface{}, opt *gitlab.ListGroupsRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return retry(ctx, r, fmt.Sprintf("listing runners of group %v", gid), nil, func() ([]*gitlab.Runner, *gitlab.Response, error) {
+		return r.GitLabClient.ListGroupsRunners(ctx, gid, opt, options...)
+	})
+}
+
+func (r *RetryClient) ListProjectRunners(ctx context.Context, pid interface{}, opt *gitlab.ListProjectRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	return retry(ctx, r, fmt.Sprintf("listing runners of project %v", pid), nil, func() ([]*gitlab.Runner, *gitlab.Response, error) {
+		return r.GitLabClient.ListProjectRunners(ctx, pid, opt, options...)
+	})
+}
+
+func (r *RetryClient) ListDescendantGroups(ctx context.Context, gid interface{}, opt *gitlab.ListDescendantGroupsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error) {
+	return retry(ctx, r, fmt.Sprintf("listing subgroups of group %v", gid), nil, func() ([]*gitlab.Group, *gitlab.Response, error) {
+		return r.GitLabClient.ListDescendantGroups(ctx, gid, opt, options...)
+	})
+}
+
+func (r *RetryClient) CurrentUser(ctx context.Context, options ...gitlab.RequestOptionFunc) (*gitlab.User, *gitlab.Response, error) {
+	return retry(ctx, r, "getting current user", nil, func() (*gitlab.User, *gitlab.Response, error) {
+		return r.GitLabClient.CurrentUser(ctx, options...)
+	})
+}
+
+// DeleteRegisteredRunnerByID treats a 404 of a retried delete as success. A
+// failed attempt may have deleted the runner anyway e.g. if a proxy returned a
+// 502 after GitLab finished the request.
+func (r *RetryClient) DeleteRegisteredRunnerByID(ctx context.Context, rid int, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	attempt := 0
+	_, resp, err := retry(ctx, r, fmt.Sprintf("deleting runner ID %d", rid), rid, func() (interface{}, *gitlab.Response, error) {
+		attempt++
+		resp, err := r.GitLabClient.DeleteRegisteredRunnerByID(ctx, rid, options...)
+		if err != nil && attempt > 1 && resp != nil && resp.Response != nil && resp.StatusCode == http.StatusNotFound {
+			r.Logger.Infof("Runner ID %d not found on attempt %d, it was deleted by a previous attempt", rid, attempt)
+			return nil, resp, nil
+		}
+		return nil, resp, err
+	})
+	return resp, err
+}
+
+func (r *RetryClient) UpdateRunnerDetails(ctx context.Context, rid interface{}, opt *gitlab.UpdateRunnerDetailsOptions, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
+	return retry(ctx, r, fmt.Sprintf("updating runner ID %v", rid), rid, func() (*gitlab.RunnerDetails, *gitlab.Response, error) {
+		return r.GitLabClient.UpdateRunnerDetails(ctx, rid, opt, options...)
+	})
+}
+
+// retry calls request until it succeeds, fails with an error which can't be
+// retried, MaxAttempts is reached, the next attempt would exceed the Timeout or
+// ctx is done. Retries of requests for a runner are counted if rid is an int.
+func retry[T any](ctx context.Context, r *RetryClient, action string, rid interface{}, request func() (T, *gitlab.Response, error)) (T, *gitlab.Response, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		result, resp, err := request()
+		if err == nil || !retryable(resp) || attempt >= r.MaxAttempts {
+			return result, resp, err
+		}
+		delay := r.delay(resp, attempt)
+		if r.Timeout > 0 && time.Since(start)+delay > r.Timeout {
+			return result, resp, fmt.Errorf("%w (giving up after %d attempts, retry timeout of %s exceeded)", err, attempt, r.Timeout)
+		}
+		r.Logger.Warnf("Error %s %s, retrying in %s (attempt %d of %d)", err, action, delay.Round(time.Millisecond), attempt+1, r.MaxAttempts)
+		if id, ok := rid.(int); ok {
+			r.mutex.Lock()
+			r.retries[id]++
+			r.mutex.Unlock()
+		}
+		if sleepErr := r.sleep(ctx, delay); sleepErr != nil {
+			return result, resp, fmt.Errorf("%w (retry aborted: %s)", err, sleepErr)
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryable(resp *gitlab.Response) bool {
+	if resp == nil || resp.Response == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// delay returns the time to wait before the next attempt. Retry-After and
+// RateLimit-Reset take precedence before the exponential backoff.
+func (r *RetryClient) delay(resp *gitlab.Response, attempt int) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(time.Until(at), 0)
+		}
+	}
+	if resp.Header.Get("RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Until(time.Unix(reset, 0)), 0)
+		}
+	}
+
+	backoff := r.BaseDelay << (attempt - 1)
+	if backoff > r.MaxDelay || backoff <= 0 {
+		backoff = r.MaxDelay
+	}
+	// Jitter between half and the full backoff
+	return backoff/2 + rand.N(backoff/2+1)
+}
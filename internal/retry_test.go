@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestRetryClient(t *testing.T) {
+	newRetryClient := func(mock *mocks.GitLabClient, maxAttempts int, timeout time.Duration) (*RetryClient, *[]time.Duration) {
+		logger, _ := logrusTest.NewNullLogger()
+		client := NewRetryClient(mock, logger, maxAttempts, timeout)
+		sleeps := &[]time.Duration{}
+		client.sleep = func(ctx context.Context, d time.Duration) error {
+			*sleeps = append(*sleeps, d)
+			return nil
+		}
+		return client, sleeps
+	}
+
+	t.Run("Retry on 5xx", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(nil, errorResponse(http.StatusBadGateway, nil), errors.New("502 Bad Gateway")).Twice()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Once()
+		client, sleeps := newRetryClient(mock, 3, 0)
+		details, _, err := client.GetRunnerDetails(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, details.ID)
+		assert.Len(t, *sleeps, 2)
+		assert.Equal(t, 2, client.Retries(1))
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Retry-After", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(errorResponse(http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}}), errors.New("429 Too Many Requests")).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(&gitlab.Response{}, nil).Once()
+		client, sleeps := newRetryClient(mock, 3, 0)
+		_, err := client.DeleteRegisteredRunnerByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{7 * time.Second}, *sleeps)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("RateLimit-Reset", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		reset := time.Now().Add(30 * time.Second).Unix()
+		header := http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {strconv.FormatInt(reset, 10)}}
+		mock.EXPECT().ListRunners(testifyMock.Anything, &gitlab.ListRunnersOptions{}).Return(nil, errorResponse(http.StatusTooManyRequests, header), errors.New("429 Too Many Requests")).Once()
+		mock.EXPECT().ListRunners(testifyMock.Anything, &gitlab.ListRunnersOptions{}).Return([]*gitlab.Runner{{ID: 1}}, &gitlab.Response{}, nil).Once()
+		client, sleeps := newRetryClient(mock, 3, 0)
+		rners, _, err := client.ListRunners(context.Background(), &gitlab.ListRunnersOptions{})
+		require.NoError(t, err)
+		assert.Len(t, rners, 1)
+		require.Len(t, *sleeps, 1)
+		assert.InDelta(t, 30*time.Second, (*sleeps)[0], float64(2*time.Second))
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("No retry on 4xx", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(nil, errorResponse(http.StatusNotFound, nil), errors.New("404 Not Found")).Once()
+		client, sleeps := newRetryClient(mock, 3, 0)
+		_, _, err := client.GetRunnerDetails(context.Background(), 1)
+		assert.EqualError(t, err, "404 Not Found")
+		assert.Empty(t, *sleeps)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Not found after retried delete", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(errorResponse(http.StatusBadGateway, nil), errors.New("502 Bad Gateway")).Once()
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(errorResponse(http.StatusNotFound, nil), errors.New("404 Not Found")).Once()
+		client, _ := newRetryClient(mock, 3, 0)
+		resp, err := client.DeleteRegisteredRunnerByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Not found on first delete", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(errorResponse(http.StatusNotFound, nil), errors.New("404 Not Found")).Once()
+		client, _ := newRetryClient(mock, 3, 0)
+		_, err := client.DeleteRegisteredRunnerByID(context.Background(), 1)
+		assert.EqualError(t, err, "404 Not Found")
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("No retry without response", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(nil, errors.New("connection refused")).Once()
+		client, sleeps := newRetryClient(mock, 3, 0)
+		_, err := client.DeleteRegisteredRunnerByID(context.Background(), 1)
+		assert.EqualError(t, err, "connection refused")
+		assert.Empty(t, *sleeps)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Max attempts", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(nil, errorResponse(http.StatusServiceUnavailable, nil), errors.New("503 Service Unavailable")).Times(3)
+		client, sleeps := newRetryClient(mock, 3, 0)
+		_, _, err := client.GetRunnerDetails(context.Background(), 1)
+		assert.EqualError(t, err, "503 Service Unavailable")
+		assert.Len(t, *sleeps, 2)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(nil, errorResponse(http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}}), errors.New("429 Too Many Requests")).Once()
+		client, sleeps := newRetryClient(mock, 5, time.Minute)
+		_, _, err := client.GetRunnerDetails(context.Background(), 1)
+		assert.EqualError(t, err, "429 Too Many Requests (giving up after 1 attempts, retry timeout of 1m0s exceeded)")
+		assert.Empty(t, *sleeps)
+		mock.AssertExpectations(t)
+	})
+}
+
+func TestRetryClientCancel(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(nil, errorResponse(http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}}), errors.New("429 Too Many Requests")).Once()
+	client := NewRetryClient(mock, logger, 3, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := client.GetRunnerDetails(ctx, 1)
+	assert.EqualError(t, err, "429 Too Many Requests (retry aborted: context deadline exceeded)")
+	assert.Less(t, time.Since(start), 10*time.Second)
+	mock.AssertExpectations(t)
+}
+
+func TestRetryClientDelay(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	client := NewRetryClient(&mocks.GitLabClient{}, logger, 10, 0)
+	resp := errorResponse(http.StatusBadGateway, nil)
+	for attempt, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: time.Minute} {
+		t.Run(fmt.Sprintf("Attempt %d", attempt), func(t *testing.T) {
+			delay := client.delay(resp, attempt)
+			assert.GreaterOrEqual(t, delay, expected/2)
+			assert.LessOrEqual(t, delay, expected)
+		})
+	}
+
+	t.Run("Retry-After date", func(t *testing.T) {
+		at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+		delay := client.delay(errorResponse(http.StatusServiceUnavailable, http.Header{"Retry-After": {at}}), 1)
+		assert.InDelta(t, time.Minute, delay, float64(2*time.Second))
+	})
+}
+
+func TestCleanupRunnersRetries(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	mock := &mocks.GitLabClient{}
+	mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Once()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(errorResponse(http.StatusInternalServerError, nil), errors.New("500 Internal Server Error")).Once()
+	mock.EXPECT().DeleteRegisteredRunnerByID(testifyMock.Anything, 1).Return(&gitlab.Response{Response: &http.Response{StatusCode: http.StatusNoContent}}, nil).Once()
+	client := NewRetryClient(mock, logger, 3, 0)
+	client.sleep = func(context.Context, time.Duration) error { return nil }
+
+	clinar := Clinar{Client: client, Logger: logger}
+	report, err := clinar.CleanupRunners(context.Background(), []*gitlab.RunnerDetails{{ID: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, []DeletionResult{{ID: 1, Outcome: OutcomeDeleted, StatusCode: http.StatusNoContent, Retries: 1}}, report.Results)
+	assert.Equal(t, 1, report.Retries)
+	mock.AssertExpectations(t)
+}
+
+func errorResponse(status int, header http.Header) *gitlab.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &gitlab.Response{Response: &http.Response{StatusCode: status, Header: header}}
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const instanceRunnerType = "instance_type"
+
+// isScoped returns true if runner discovery is limited to groups or projects.
+func (c Clinar) isScoped() bool {
+	return len(c.Groups) > 0 || len(c.Projects) > 0
+}
+
+// runnerSources returns the list functions which are used to discover runners.
+// If Groups or Projects are set the group and project runner endpoints are used,
+// otherwise all runners the token owner can administer (or all runners of the
+// instance if AllRunners is set) are listed.
+func (c *Clinar) runnerSources(ctx context.Context) ([]listRunnersFunc, error) {
+	if !c.isScoped() {
+		if c.AllRunners {
+			if err := c.verifyAdmin(ctx); err != nil {
+				return nil, err
+			}
+			return []listRunnersFunc{c.Client.ListAllRunners}, nil
+		}
+		return []listRunnersFunc{c.Client.ListRunners}, nil
+	}
+
+	sources := []listRunnersFunc{}
+	groups, err := c.scopedGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, gid := range groups {
+		sources = append(sources, c.groupRunners(gid))
+	}
+	for _, pid := range c.Projects {
+		sources = append(sources, c.projectRunners(pid))
+	}
+	return sources, nil
+}
+
+// scopedGroups returns the configured Groups and if IncludeSubgroups is set all
+// of their descendant groups. The groups are only resolved once.
+func (c *Clinar) scopedGroups(ctx context.Context) ([]string, error) {
+	if c.scopeGroups != nil {
+		return c.scopeGroups, nil
+	}
+	groups := []string{}
+	seen := map[string]bool{}
+	add := func(gid string) {
+		if !seen[gid] {
+			seen[gid] = true
+			groups = append(groups, gid)
+		}
+	}
+
+	for _, gid := range c.Groups {
+		add(gid)
+		if !c.IncludeSubgroups {
+			continue
+		}
+		opts := &gitlab.ListDescendantGroupsOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: 100,
+				Page:    1,
+			},
+		}
+		for {
+			descendants, resp, err := c.Client.ListDescendantGroups(ctx, gid, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, grp := range descendants {
+				add(strconv.Itoa(grp.ID))
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	c.scopeGroups = groups
+	return groups, nil
+}
+
+// isInScope returns true if the runner belongs to one of the scoped groups or
+// projects. The group and project endpoints also return the runners of ancestor
+// groups, those are outside of the scope. If IncludeSubgroups is set groups
+// below the path of a scoped group are part of the scope as well.
+func (c Clinar) isInScope(details *gitlab.RunnerDetails) bool {
+	if !c.isScoped() {
+		return true
+	}
+	groups := c.scopeGroups
+	if groups == nil {
+		groups = c.Groups
+	}
+	for _, grp := range details.Groups {
+		if matchesScope(groups, grp.ID, groupPath(grp.WebURL), c.IncludeSubgroups) {
+			return true
+		}
+	}
+	for _, proj := range details.Projects {
+		if matchesScope(c.Projects, proj.ID, proj.PathWithNamespace, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesScope returns true if one of the scope entries is the id or the path.
+// If subpaths is set paths below a scope entry match as well.
+func matchesScope(scope []string, id int, path string, subpaths bool) bool {
+	for _, entry := range scope {
+		entry = strings.Trim(entry, "/")
+		if entry == strconv.Itoa(id) {
+			return true
+		}
+		if path != "" && (entry == path || (subpaths && strings.HasPrefix(path, entry+"/"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupRunners lists the runners of a group. The group endpoint doesn't support
+// the paused option so paused runners are filtered afterwards.
+func (c *Clinar) groupRunners(gid string) listRunnersFunc {
+	return func(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+		grpOpts := &gitlab.ListGroupsRunnersOptions{
+			ListOptions: opt.ListOptions,
+			Type:        opt.Type,
+			Status:      opt.Status,
+			TagList:     opt.TagList,
+		}
+		rners, resp, err := c.Client.ListGroupsRunners(ctx, gid, grpOpts, options...)
+		if err != nil || opt.Paused == nil {
+			return rners, resp, err
+		}
+		paused := []*gitlab.Runner{}
+		for _, rner := range rners {
+			if rner.Paused == *opt.Paused {
+				paused = append(paused, rner)
+			}
+		}
+		return paused, resp, nil
+	}
+}
+
+func (c *Clinar) projectRunners(pid string) listRunnersFunc {
+	return func(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+		return c.Client.ListProjectRunners(ctx, pid, (*gitlab.ListProjectRunnersOptions)(opt), options...)
+	}
+}
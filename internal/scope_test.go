@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestGetAllRunnersScoped(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+
+	t.Run("Group and project", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListGroupsRunners(testifyMock.Anything, "platform", groupRunnersOptions(defaultRunnerState)).
+			Return([]*gitlab.Runner{{ID: 1, RunnerType: "group_type"}, {ID: 2, RunnerType: instanceRunnerType}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		mock.EXPECT().ListProjectRunners(testifyMock.Anything, "platform/infra", (*gitlab.ListProjectRunnersOptions)(listRunnersOptions(defaultRunnerState, nil))).
+			Return([]*gitlab.Runner{{ID: 1, RunnerType: "group_type"}, {ID: 3, RunnerType: "project_type"}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, Groups: []string{"platform"}, Projects: []string{"platform/infra"}}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		require.Len(t, rners, 2)
+		assert.Equal(t, 1, rners[0].ID)
+		assert.Equal(t, 3, rners[1].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Group with subgroups", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListDescendantGroups(testifyMock.Anything, "42", &gitlab.ListDescendantGroupsOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1}}).
+			Return([]*gitlab.Group{{ID: 43}}, &gitlab.Response{NextPage: 2}, nil).Once()
+		mock.EXPECT().ListDescendantGroups(testifyMock.Anything, "42", &gitlab.ListDescendantGroupsOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 2}}).
+			Return([]*gitlab.Group{{ID: 44}}, &gitlab.Response{}, nil).Once()
+		for i, gid := range []string{"42", "43", "44"} {
+			mock.EXPECT().ListGroupsRunners(testifyMock.Anything, gid, groupRunnersOptions(defaultRunnerState)).
+				Return([]*gitlab.Runner{{ID: i + 1, RunnerType: "group_type"}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		}
+		clinar := Clinar{Client: mock, Logger: logger, Groups: []string{"42"}, IncludeSubgroups: true}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, rners, 3)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Paused group runners", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListGroupsRunners(testifyMock.Anything, "platform", &gitlab.ListGroupsRunnersOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1}}).
+			Return([]*gitlab.Runner{{ID: 1, Paused: true}, {ID: 2}}, &gitlab.Response{TotalPages: 1}, nil).Once()
+		clinar := Clinar{Client: mock, Logger: logger, Groups: []string{"platform"}, States: []string{"paused"}}
+		rners, err := clinar.GetAllRunners(context.Background())
+		require.NoError(t, err)
+		require.Len(t, rners, 1)
+		assert.Equal(t, 1, rners[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Error listing subgroups", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().ListDescendantGroups(testifyMock.Anything, "42", &gitlab.ListDescendantGroupsOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1}}).
+			Return(nil, &gitlab.Response{}, errors.New("Something went wrong")).Once()
+		clinar := Clinar{Client: mock, Logger: logger, Groups: []string{"42"}, IncludeSubgroups: true}
+		rners, err := clinar.GetAllRunners(context.Background())
+		assert.Nil(t, rners)
+		assert.EqualError(t, err, "Something went wrong")
+		mock.AssertExpectations(t)
+	})
+}
+
+func TestGetRunnerDetailsScoped(t *testing.T) {
+	logger, _ := logrusTest.NewNullLogger()
+	mock := &mocks.GitLabClient{}
+	runners := []*gitlab.RunnerDetails{
+		runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform", ""),
+		runnerDetailsWithPaths(2, "https://gitlab.com/groups/platform/team", ""),
+		runnerDetailsWithPaths(3, "https://gitlab.com/groups/platform/team/sub", ""),
+		runnerDetailsWithPaths(4, "", "other/app"),
+		runnerDetailsWithPaths(5, "", "other/tool"),
+	}
+	for _, rner := range runners {
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, rner.ID).Return(rner, &gitlab.Response{}, nil).Once()
+	}
+
+	// The ancestor group runner 1 is also returned by the group and project endpoints
+	clinar := Clinar{Client: mock, Logger: logger, Groups: []string{"platform/team"}, Projects: []string{"other/app"}}
+	rners, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}})
+	require.NoError(t, err)
+	ids := []int{}
+	for _, rner := range rners {
+		ids = append(ids, rner.ID)
+	}
+	assert.Equal(t, []int{2, 4}, ids)
+	mock.AssertExpectations(t)
+
+	clinar.IncludeSubgroups = true
+	assert.True(t, clinar.isInScope(runners[2]))
+	assert.False(t, clinar.isInScope(runners[0]))
+	assert.True(t, Clinar{Groups: []string{"103"}}.isInScope(runners[2]))
+	assert.True(t, Clinar{Groups: []string{"42"}, scopeGroups: []string{"42", "103"}}.isInScope(runners[2]))
+	assert.True(t, Clinar{}.isInScope(runners[0]))
+}
+
+func groupRunnersOptions(state string) *gitlab.ListGroupsRunnersOptions {
+	opts := listRunnersOptions(state, nil)
+	return &gitlab.ListGroupsRunnersOptions{ListOptions: opts.ListOptions, Status: opts.Status}
+}
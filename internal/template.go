@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	TemplateScopeRunner  = "runner"
+	TemplateScopeSummary = "summary"
+)
+
+// Summary is the data of templates with the summary scope.
+type Summary struct {
+	// Total is the number of listed runners the selection was made from.
+	Total int
+	// Count is the number of selected runners.
+	Count       int
+	ByType      map[string]int
+	ByStatus    map[string]int
+	Runners     []*gitlab.RunnerDetails
+	GeneratedAt time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	"ago":  ago,
+	"join": join,
+	"json": toJSON,
+}
+
+// ParseTemplate parses a Go template which can use the functions ago, join and json.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("output").Funcs(templateFuncs).Parse(text)
+}
+
+// WriteTemplate executes the template for each runner or once with a Summary
+// if scope is summary. Each runner is written on its own line.
+func WriteTemplate(w io.Writer, tmpl *template.Template, scope string, total int, runners []*gitlab.RunnerDetails) error {
+	runners = withoutTokens(runners)
+	if scope == TemplateScopeSummary {
+		return tmpl.Execute(w, NewSummary(total, runners))
+	}
+	for _, rner := range runners {
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, rner); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteString("\n")
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSummary counts the given runners by type and status.
+func NewSummary(total int, runners []*gitlab.RunnerDetails) Summary {
+	summary := Summary{
+		Total:       total,
+		Count:       len(runners),
+		ByType:      map[string]int{},
+		ByStatus:    map[string]int{},
+		Runners:     runners,
+		GeneratedAt: time.Now(),
+	}
+	for _, rner := range runners {
+		summary.ByType[rner.RunnerType]++
+		summary.ByStatus[rner.Status]++
+	}
+	return summary
+}
+
+// ago returns a relative time for time.Time and *time.Time values. A nil
+// pointer returns never.
+func ago(value interface{}) (string, error) {
+	switch t := value.(type) {
+	case time.Time:
+		return TimeAgo(t), nil
+	case *time.Time:
+		if t == nil {
+			return "never", nil
+		}
+		return TimeAgo(*t), nil
+	}
+	return "", fmt.Errorf("ago expects a time but got %T", value)
+}
+
+// join joins the values with the separator. It can be used in pipelines e.g.
+// {{.TagList | join ", "}}.
+func join(sep string, values interface{}) (string, error) {
+	switch v := values.(type) {
+	case []string:
+		return strings.Join(v, sep), nil
+	case []interface{}:
+		parts := []string{}
+		for _, part := range v {
+			parts = append(parts, fmt.Sprint(part))
+		}
+		return strings.Join(parts, sep), nil
+	}
+	return "", fmt.Errorf("join expects a list but got %T", values)
+}
+
+func toJSON(value interface{}) (string, error) {
+	content, err := json.Marshal(value)
+	return string(content), err
+}
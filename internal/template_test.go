@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestWriteTemplate(t *testing.T) {
+	first := runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform", "")
+	first.Description = "first"
+	first.RunnerType = "group_type"
+	first.Status = "offline"
+	first.Token = "secret"
+	first.TagList = []string{"docker", "gpu"}
+	first.ContactedAt = gitlab.Ptr(time.Now().Add(-22 * 24 * time.Hour))
+	second := &gitlab.RunnerDetails{ID: 2, Description: "second", RunnerType: "project_type", Status: "never_contacted"}
+	runners := []*gitlab.RunnerDetails{first, second}
+
+	t.Run("Runner scope", func(t *testing.T) {
+		tmpl, err := ParseTemplate(`{{.ID}} {{.Description}} {{range .Groups}}{{.Name}} {{end}}- {{.TagList | join ", "}} - {{ago .ContactedAt}}`)
+		require.NoError(t, err)
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteTemplate(out, tmpl, TemplateScopeRunner, 10, runners))
+		assert.Equal(t, "1 first Group1 - docker, gpu - 3 weeks ago\n2 second - \x20- never\n", out.String())
+	})
+
+	t.Run("JSON helper without token", func(t *testing.T) {
+		tmpl, err := ParseTemplate(`{{json .TagList}} {{.Token}}`)
+		require.NoError(t, err)
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteTemplate(out, tmpl, TemplateScopeRunner, 10, runners[:1]))
+		assert.Equal(t, "[\"docker\",\"gpu\"] \n", out.String())
+	})
+
+	t.Run("Summary scope", func(t *testing.T) {
+		tmpl, err := ParseTemplate(`{{.Count}} of {{.Total}} runners: {{index .ByType "group_type"}} group, {{index .ByStatus "never_contacted"}} never contacted{{range .Runners}} #{{.ID}}{{end}}`)
+		require.NoError(t, err)
+		out := &bytes.Buffer{}
+		require.NoError(t, WriteTemplate(out, tmpl, TemplateScopeSummary, 10, runners))
+		assert.Equal(t, "2 of 10 runners: 1 group, 1 never contacted #1 #2", out.String())
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		_, err := ParseTemplate(`{{.ID`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid helper argument", func(t *testing.T) {
+		tmpl, err := ParseTemplate(`{{ago .ID}}`)
+		require.NoError(t, err)
+		err = WriteTemplate(&bytes.Buffer{}, tmpl, TemplateScopeRunner, 10, runners)
+		assert.ErrorContains(t, err, "ago expects a time but got int")
+	})
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// CompileWhere compiles a CEL expression which must evaluate to a bool. The
+// expression can access the variable runner (see runnerObject) and the
+// current time as now.
+func CompileWhere(expression string) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("runner", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("now", cel.TimestampType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	// Fields of runner are dyn, their type is only checked on evaluation
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("where expression must return a bool but returns %s", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// matchesWhere evaluates the Where expression against the given runner. If the
+// expression can't be evaluated or doesn't return a bool the runner isn't selected.
+func (c Clinar) matchesWhere(details *gitlab.RunnerDetails) bool {
+	if c.Where == nil {
+		return true
+	}
+	out, _, err := c.Where.Eval(map[string]interface{}{
+		"runner": runnerObject(details),
+		"now":    time.Now(),
+	})
+	if err != nil {
+		c.Logger.Errorf("Error %s evaluating where expression for runner ID %d", err, details.ID)
+		return false
+	}
+	matches, ok := out.Value().(bool)
+	if !ok {
+		c.Logger.Errorf("Error where expression returned %v instead of a bool for runner ID %d", out.Value(), details.ID)
+	}
+	return ok && matches
+}
+
+// runnerObject converts the runner details into the object which is available
+// as runner in where expressions. contacted_at is only set if the runner ever
+// contacted GitLab and the version parts are only set if the version can be parsed.
+func runnerObject(details *gitlab.RunnerDetails) map[string]interface{} {
+	groups := []map[string]interface{}{}
+	for _, grp := range details.Groups {
+		groups = append(groups, map[string]interface{}{
+			"id":   grp.ID,
+			"name": grp.Name,
+			"path": groupPath(grp.WebURL),
+		})
+	}
+	projects := []map[string]interface{}{}
+	for _, proj := range details.Projects {
+		projects = append(projects, map[string]interface{}{
+			"id":   proj.ID,
+			"name": proj.Name,
+			"path": proj.PathWithNamespace,
+		})
+	}
+	tags := details.TagList
+	if tags == nil {
+		tags = []string{}
+	}
+
+	obj := map[string]interface{}{
+		"id":               details.ID,
+		"name":             details.Name,
+		"description":      details.Description,
+		"type":             details.RunnerType,
+		"status":           details.Status,
+		"online":           details.Online,
+		"paused":           details.Paused,
+		"is_shared":        details.IsShared,
+		"tags":             tags,
+		"run_untagged":     details.RunUntagged,
+		"locked":           details.Locked,
+		"access_level":     details.AccessLevel,
+		"maximum_timeout":  details.MaximumTimeout,
+		"maintenance_note": details.MaintenanceNote,
+		"groups":           groups,
+		"projects":         projects,
+		"version":          details.Version,
+		"revision":         details.Revision,
+		"platform":         details.Platform,
+		"architecture":     details.Architecture,
+	}
+	if details.ContactedAt != nil {
+		obj["contacted_at"] = *details.ContactedAt
+	}
+	if version, err := ParseVersion(details.Version); err == nil {
+		obj["version_major"] = int(version.Major)
+		obj["version_minor"] = int(version.Minor)
+		obj["version_patch"] = int(version.Patch)
+	}
+	return obj
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrusTest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/steffakasid/clinar/mocks"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestCompileWhere(t *testing.T) {
+	t.Run("Valid expression", func(t *testing.T) {
+		prg, err := CompileWhere(`runner.type == "project_type" && !("keep" in runner.tags)`)
+		require.NoError(t, err)
+		assert.NotNil(t, prg)
+	})
+
+	t.Run("Runner field", func(t *testing.T) {
+		for _, expression := range []string{`runner.paused`, `runner.online`, `!runner.locked`} {
+			prg, err := CompileWhere(expression)
+			require.NoError(t, err, expression)
+			assert.NotNil(t, prg)
+		}
+	})
+
+	t.Run("Syntax error", func(t *testing.T) {
+		_, err := CompileWhere(`runner.type ==`)
+		assert.Error(t, err)
+	})
+
+	t.Run("Not a bool", func(t *testing.T) {
+		_, err := CompileWhere(`runner.id + 1`)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetRunnerDetailsWhere(t *testing.T) {
+	logger, logHook := logrusTest.NewNullLogger()
+
+	t.Run("Select by expression", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, RunnerType: "project_type", TagList: []string{"docker"}, ContactedAt: gitlab.Ptr(time.Now().Add(-30 * 24 * time.Hour))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, RunnerType: "project_type", TagList: []string{"keep"}, ContactedAt: gitlab.Ptr(time.Now().Add(-30 * 24 * time.Hour))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3, RunnerType: "project_type", ContactedAt: gitlab.Ptr(time.Now().Add(-time.Hour))}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 4).Return(&gitlab.RunnerDetails{ID: 4, RunnerType: "group_type"}, &gitlab.Response{}, nil).Once()
+		where, err := CompileWhere(`runner.type == "project_type" && !("keep" in runner.tags) && has(runner.contacted_at) && runner.contacted_at < now - duration("336h")`)
+		require.NoError(t, err)
+		clinar := Clinar{Client: mock, Logger: logger, Where: where}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		assert.Equal(t, 1, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Groups and projects", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(runnerDetailsWithPaths(1, "https://gitlab.com/groups/platform/team-a", ""), &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(runnerDetailsWithPaths(2, "", "other/infra"), &gitlab.Response{}, nil).Once()
+		where, err := CompileWhere(`runner.groups.exists(g, g.path.startsWith("platform/")) || runner.projects.exists(p, p.name == "Project3")`)
+		require.NoError(t, err)
+		clinar := Clinar{Client: mock, Logger: logger, Where: where}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		assert.Equal(t, 1, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Version: "9.0.1"}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2, Version: "16.11.0"}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 3).Return(&gitlab.RunnerDetails{ID: 3}, &gitlab.Response{}, nil).Once()
+		where, err := CompileWhere(`has(runner.version_major) && runner.version_major < 16`)
+		require.NoError(t, err)
+		clinar := Clinar{Client: mock, Logger: logger, Where: where}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}, {ID: 3}})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		assert.Equal(t, 1, details[0].ID)
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Runner field", func(t *testing.T) {
+		logHook.Reset()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1, Paused: true}, &gitlab.Response{}, nil).Once()
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 2).Return(&gitlab.RunnerDetails{ID: 2}, &gitlab.Response{}, nil).Once()
+		where, err := CompileWhere(`runner.paused`)
+		require.NoError(t, err)
+		clinar := Clinar{Client: mock, Logger: logger, Where: where, States: []string{"offline", "paused"}}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}, {ID: 2}})
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		assert.Equal(t, 1, details[0].ID)
+
+		// A dyn expression which doesn't return a bool doesn't select the runner
+		clinar.Where, err = CompileWhere(`runner.name`)
+		require.NoError(t, err)
+		assert.False(t, clinar.matchesWhere(&gitlab.RunnerDetails{ID: 3, Name: "third"}))
+		assert.Contains(t, logHook.LastEntry().Message, "instead of a bool for runner ID 3")
+		mock.AssertExpectations(t)
+	})
+
+	t.Run("Evaluation error", func(t *testing.T) {
+		logHook.Reset()
+		mock := &mocks.GitLabClient{}
+		mock.EXPECT().GetRunnerDetails(testifyMock.Anything, 1).Return(&gitlab.RunnerDetails{ID: 1}, &gitlab.Response{}, nil).Once()
+		where, err := CompileWhere(`runner.contacted_at < now`)
+		require.NoError(t, err)
+		clinar := Clinar{Client: mock, Logger: logger, Where: where}
+		details, err := clinar.GetRunnerDetails(context.Background(), []*gitlab.Runner{{ID: 1}})
+		require.NoError(t, err)
+		assert.Len(t, details, 0)
+		require.NotEmpty(t, logHook.Entries)
+		assert.Contains(t, logHook.Entries[0].Message, "evaluating where expression for runner ID 1")
+		mock.AssertExpectations(t)
+	})
+}
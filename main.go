@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -12,14 +17,63 @@ import (
 	"github.com/spf13/viper"
 	"github.com/steffakasid/clinar/internal"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/term"
+)
+
+const (
+	planCommand           = "plan"
+	applyCommand          = "apply"
+	verifyAuditLogCommand = "verify-audit-log"
+	restoreInfoCommand    = "restore-info"
 )
 
 var clinar *internal.Clinar = &internal.Clinar{Logger: logrus.StandardLogger()}
 
+// outputTemplate is set if --template or --template-file is given.
+var outputTemplate *template.Template
+
 func init() {
 	flag.BoolP(APPROVE, "a", false, "Acknowledge to purge all stale runners")
-	flag.StringArrayP(EXCLUDE, "e", nil, "Filter out runners with specified groups/projects. Filter can be given by id or name. Exclude takes precedences before include.")
-	flag.StringP(INCLUDE, "i", "", "Regular expression include filter. Matches on project and group names. If runner is set one group or project this runner will be included.")
+	flag.Duration(TIMEOUT, 0, "Overall time clinar may run e.g. 10m. When it is exceeded no further runners are deleted. 0 means no timeout.")
+	flag.BoolP(INTERACTIVE, "I", false, "Select the runners to delete from a checklist. Requires a terminal. Confirming the selection deletes the checked runners.")
+	flag.BoolP(QUARANTINE, "q", false, "Pause stale runners instead of deleting them. Runners are deleted on a later run with --approve after the grace period. Quarantined runners which are back online are un-paused.")
+	flag.String(GRACE_PERIOD, "7d", "Time runners stay in quarantine before they are deleted e.g. 72h or 14d.")
+	flag.Int(MAX_DELETE, 0, "Abort without deleting anything if more runners are selected for deletion. 0 means no limit.")
+	flag.Float64(MAX_DELETE_PERCENT, 0, "Abort without deleting anything if more than this percentage of all listed runners is selected for deletion. 0 means no limit.")
+	flag.Bool(FORCE, false, "Delete runners even if --max-delete or --max-delete-percent is exceeded.")
+	flag.Int(DELETE_PARALLELISM, 5, "Number of runners which are deleted in parallel.")
+	flag.Float64(DELETE_RATE, 0, "Maximum number of delete requests per second. 0 means no limit.")
+	flag.Int(BATCH_SIZE, 0, "Number of runners which are deleted before pausing for --batch-pause. 0 deletes all runners in one batch.")
+	flag.Duration(BATCH_PAUSE, 0, "Pause between two batches of deletions e.g. 30s.")
+	flag.Int(RETRY_MAX_ATTEMPTS, 5, "Number of attempts of requests which failed with a 5xx or 429 status. 1 disables retries.")
+	flag.Duration(RETRY_TIMEOUT, 5*time.Minute, "Overall time all attempts of a request may take. 0 means no timeout.")
+	flag.String(AUDIT_LOG, "", "File to append a JSON Lines record of every deleted or skipped runner to. The records are chained by hashes to detect tampering.")
+	flag.String(BACKUP_DIR, ".", "Directory the full details of runners are written to before they are deleted. Set to an empty string to disable backups.")
+	flag.String(OUTPUT, internal.OutputText, "Output format of the found runners. One of text, json, yaml, csv or table.")
+	flag.String(TEMPLATE, "", "Go template to print the found runners with. Takes precedence over --output. See README for the available fields and functions.")
+	flag.String(TEMPLATE_FILE, "", "File with a Go template to print the found runners with. See --template.")
+	flag.String(TEMPLATE_SCOPE, internal.TemplateScopeRunner, "Defines if the template is executed for each runner or once with a summary. One of runner or summary.")
+	flag.StringP(OUT, "o", "", "File to write the plan to. If not set the plan is written to stdout.")
+	flag.StringArrayP(EXCLUDE, "e", nil, "Filter out runners with specified groups/projects. Filter can be given by id, name or full path. A full path also excludes all subgroups and projects. Use the prefix regex: or glob: to match names by a regular expression or glob. Exclude takes precedences before include.")
+	flag.StringP(INCLUDE, "i", "", "Regular expression include filter. Matches on project and group names and full paths. If runner is set one group or project this runner will be included.")
+	flag.StringArrayP(STATUS, "s", []string{"offline"}, "Select runners with the given status (online, offline, stale, never_contacted or paused). Can be given multiple times.")
+	flag.StringArrayP(TYPE, "t", nil, "Select runners with the given type (instance, group or project). Can be given multiple times.")
+	flag.Bool(ALL, false, "List all runners of the GitLab instance. Requires a token with administrator rights.")
+	flag.StringArrayP(GROUP, "g", nil, "Only list runners of the given group. Group can be given by id or full path. Can be given multiple times.")
+	flag.StringArrayP(PROJECT, "p", nil, "Only list runners of the given project. Project can be given by id or full path. Can be given multiple times.")
+	flag.Int(CONCURRENCY, 10, "Number of runner details which are fetched from GitLab in parallel.")
+	flag.Bool(INCLUDE_SUBGROUPS, false, "Also list runners of all subgroups of the groups given with --group.")
+	flag.StringArray(INCLUDE_TAG, nil, "Only select runners with the given tag. Can be given multiple times.")
+	flag.StringArray(EXCLUDE_TAG, nil, "Filter out runners with the given tag. Can be given multiple times. Exclude takes precedences before include.")
+	flag.String(TAG_MATCH, "any", "Defines if runners must have any or all of the tags given by --include-tag and --exclude-tag.")
+	flag.StringArray(PROTECT_TAG, []string{"clinar-keep"}, "Runners with this tag are never selected, no matter what other filters say. Can be given multiple times.")
+	flag.String(MIN_VERSION, "", "Only select runners with this version or newer e.g. 15.0.")
+	flag.String(MAX_VERSION, "", "Only select runners with a version lower than this e.g. 16.0.")
+	flag.StringArray(PLATFORM, nil, "Only select runners with the given platform e.g. linux. Can be given multiple times.")
+	flag.StringArray(ARCHITECTURE, nil, "Only select runners with the given architecture e.g. amd64. Can be given multiple times.")
+	flag.String(OLDER_THAN, "", "Only select runners which didn't contact GitLab for the given duration e.g. 72h, 30d or 2w.")
+	flag.Bool(SKIP_NEVER_CONTACTED, false, "Skip runners which never contacted GitLab. By default they are selected.")
+	flag.StringP(WHERE, "w", "", "CEL expression which must be true for a runner to be selected. See README for the available runner fields.")
 
 	flag.Usage = func() {
 		w := os.Stderr
@@ -33,6 +87,11 @@ which are offline with some additional information. After you provide the
 
 Usage:
   clinar [flags]
+  clinar plan [flags]          - write all stale runners into a plan which can be reviewed
+  clinar apply <plan file>     - delete exactly the runners of a plan, skipping runners which changed since the plan was created
+  clinar verify-audit-log <file>
+                               - verify the hash chain of an audit log
+  clinar restore-info <backup> - print gitlab-runner register commands to recreate the runners of a backup
 
 Variables:
   - GITLAB_TOKEN   - the GitLab token to access the Gitlab instance
@@ -42,7 +101,41 @@ Examples:
   clinar                       - get all stale runners which can be administred by the GITLAB_TOKEN
   clinar --approve             - cleanup all stal runners which can be administred by the GITLAB_TOKEN 
   clinar --exclude 1234        - get all stale runners which can be administred by the GITLAB_TOKEN. Excluding project or group with ID 1234.
+  clinar --exclude 'glob:team-*-prod'
+                               - get all stale runners which can be administred by the GITLAB_TOKEN. Excluding projects or groups matching team-*-prod.
+  clinar --exclude platform    - get all stale runners which can be administred by the GITLAB_TOKEN. Excluding the group platform and all of its subgroups and projects.
   clinar --include ^prefix.*   - get alle stale runners which are set on a group / project where the name matches ^prefix.*
+  clinar --status stale --status never_contacted
+                               - get all stale and never contacted runners which can be administred by the GITLAB_TOKEN
+  clinar --type project        - get all stale project runners which can be administred by the GITLAB_TOKEN
+  clinar --all                 - get all stale runners of the GitLab instance (requires admin rights)
+  clinar --group platform --include-subgroups
+                               - get all stale runners of the group platform and all of its subgroups
+  clinar --include-tag docker --include-tag gpu --tag-match all
+                               - get all stale runners which have the tags docker and gpu
+  clinar --older-than 30d      - get all stale runners which didn't contact GitLab within the last 30 days
+  clinar --status online --max-version 16.0
+                               - get all online runners with a version lower than 16.0 e.g. to plan an upgrade
+  clinar --output json         - get all stale runners which can be administred by the GITLAB_TOKEN with their full details as JSON
+  clinar --template '{{.ID}} {{.Description}} {{ago .ContactedAt}}'
+                               - get all stale runners which can be administred by the GITLAB_TOKEN printed with a Go template
+  clinar --template-scope summary --template '{{.Count}} of {{.Total}} runners are stale'
+                               - print a summary of the stale runners
+  clinar --interactive         - select the stale runners to delete from a checklist
+  clinar --approve --max-delete 50 --max-delete-percent 20
+                               - cleanup all stale runners but abort if more than 50 runners or 20% of the listed runners would be deleted
+  clinar --approve --delete-rate 2 --batch-size 100 --batch-pause 1m
+                               - cleanup all stale runners with at most 2 delete requests per second and a pause of one minute after every 100 runners
+  clinar --approve --quarantine
+                               - pause all stale runners and delete runners which are paused by clinar for longer than the grace period
+  clinar --approve --audit-log /var/log/clinar.jsonl
+                               - cleanup all stale runners and append a record of every runner to /var/log/clinar.jsonl
+  clinar restore-info clinar-backup-20240102T030405.000Z.json
+                               - print the gitlab-runner register settings of the runners deleted by a run
+  clinar plan -o plan.json     - write a plan of all stale runners which can be administred by the GITLAB_TOKEN to plan.json
+  clinar apply plan.json       - delete all runners of plan.json which didn't change since the plan was created
+  clinar --where 'runner.type == "project_type" && !("keep" in runner.tags)'
+                               - get all stale project runners which don't have the tag keep
 
 Flags:`)
 
@@ -58,48 +151,225 @@ Flags:`)
 }
 
 func main() {
-	var err error
-
+	switch flag.Arg(0) {
+	case verifyAuditLogCommand:
+		verifyAuditLog(flag.Arg(1))
+		return
+	case restoreInfoCommand:
+		restoreInfo(flag.Arg(1))
+		return
+	}
 	if viper.GetString(GTILAB_TOKEN) == "" {
 		logger.Fatal("GITLAB_TOKEN env var not set")
 	} else {
-		gitLabClient, err := gitlab.NewClient(viper.GetString(GTILAB_TOKEN), gitlab.WithBaseURL(viper.GetString(GITLAB_HOST)))
+		// Retries are done by the RetryClient to count them in the report
+		gitLabClient, err := gitlab.NewClient(viper.GetString(GTILAB_TOKEN), gitlab.WithBaseURL(viper.GetString(GITLAB_HOST)), gitlab.WithoutRetries())
 		if err != nil {
 			logger.Fatalf("Failed to create client: %v", err)
 		}
-		clinar.Client = gitLabClient.Runners
+		clinar.Client = internal.NewRetryClient(internal.NewGitLabClient(gitLabClient), clinar.Logger, viper.GetInt(RETRY_MAX_ATTEMPTS), viper.GetDuration(RETRY_TIMEOUT))
+	}
+	ctx, cancel := newContext(viper.GetDuration(TIMEOUT))
+	if viper.GetString(AUDIT_LOG) != "" {
+		clinar.AuditLog = openAuditLog(ctx, viper.GetString(AUDIT_LOG))
 	}
 
 	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond, spinner.WithWriter(os.Stderr))
 	s.Start()
-	rners, err := clinar.GetAllRunners()
+	exitCode := internal.ExitOK
+	switch flag.Arg(0) {
+	case "":
+		interactive := viper.GetBool(INTERACTIVE)
+		if interactive && (!term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd()))) {
+			logger.Fatalf("--%s requires a terminal", INTERACTIVE)
+		}
+		rnerDetails := findStaleRunners(ctx)
+		approved := viper.GetBool(APPROVE)
+		if interactive && len(rnerDetails) > 0 {
+			s.Stop()
+			rnerDetails = selectRunners(rnerDetails)
+			approved = true
+			s.Start()
+		}
+		if approved && viper.GetBool(QUARANTINE) {
+			if err := clinar.ReleaseQuarantinedRunners(ctx); err != nil {
+				logger.Error(err)
+			}
+			exitCode = writeReport(clinar.QuarantineRunners(ctx, rnerDetails))
+		} else if approved {
+			exitCode = writeReport(clinar.CleanupRunners(ctx, rnerDetails))
+		} else if outputTemplate != nil {
+			if err := internal.WriteTemplate(os.Stdout, outputTemplate, viper.GetString(TEMPLATE_SCOPE), clinar.TotalRunners, rnerDetails); err != nil {
+				logger.Fatal(err)
+			}
+		} else {
+			if err := internal.WriteRunners(os.Stdout, viper.GetString(OUTPUT), rnerDetails); err != nil {
+				logger.Fatal(err)
+			}
+		}
+	case planCommand:
+		writePlan(findStaleRunners(ctx))
+	case applyCommand:
+		exitCode = applyPlan(ctx, flag.Arg(1))
+	default:
+		logger.Fatalf("Unknown command %s", flag.Arg(0))
+	}
+	s.Stop()
+	cancel()
+	if clinar.AuditLog != nil {
+		if err := clinar.AuditLog.Close(); err != nil {
+			logger.Error(err)
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// newContext returns a context which is cancelled on SIGINT or SIGTERM or after
+// the timeout if it is greater than 0. After the first signal a second one
+// terminates clinar immediately.
+func newContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Warnf("Received %s, waiting for running deletions to finish. Repeat to exit immediately", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	if timeout <= 0 {
+		return ctx, cancel
+	}
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return timeoutCtx, func() {
+		cancelTimeout()
+		cancel()
+	}
+}
+
+func findStaleRunners(ctx context.Context) []*gitlab.RunnerDetails {
+	rners, err := clinar.GetAllRunners(ctx)
 	if err != nil {
 		logger.Fatal(err)
 	}
-	rnerDetails := clinar.GetRunnerDetails(rners)
-	if viper.GetBool(APPROVE) {
-		clinar.CleanupRunners(rnerDetails)
-	} else {
-		printFoundRunners(rnerDetails)
+	details, err := clinar.GetRunnerDetails(ctx, rners)
+	if err != nil {
+		logger.Fatal(err)
 	}
-	s.Stop()
+	return details
 }
 
-func printFoundRunners(staleRunnerIds []*gitlab.RunnerDetails) {
-	if len(staleRunnerIds) > 0 {
-		fmt.Println()
-		for _, rner := range staleRunnerIds {
-			groups := []string{}
-			for _, grp := range rner.Groups {
-				groups = append(groups, grp.Name)
-			}
-			projects := []string{}
-			for _, proj := range rner.Projects {
-				projects = append(projects, proj.Name)
-			}
-			fmt.Printf("%d - %s - %s - %t - %s - %s\n", rner.ID, rner.RunnerType, rner.Description, rner.Online, groups, projects)
+func selectRunners(staleRunners []*gitlab.RunnerDetails) []*gitlab.RunnerDetails {
+	selected, err := internal.SelectRunners(os.Stdin, os.Stdout, staleRunners)
+	if errors.Is(err, internal.ErrAborted) {
+		logger.Info("Selection aborted, no runners deleted")
+		os.Exit(0)
+	} else if err != nil {
+		logger.Fatal(err)
+	}
+	return selected
+}
+
+func writePlan(staleRunners []*gitlab.RunnerDetails) {
+	plan, err := internal.NewPlan(viper.GetString(GITLAB_HOST), clinar.TotalRunners, staleRunners)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	out := os.Stdout
+	if viper.GetString(OUT) != "" {
+		out, err = os.Create(viper.GetString(OUT))
+		if err != nil {
+			logger.Fatal(err)
 		}
-	} else {
-		fmt.Println("No stale runners found!")
+		defer out.Close()
+	}
+	if err := plan.Write(out); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Infof("Plan with %d runners written", len(plan.Runners))
+}
+
+// writeReport writes the report of a cleanup run to stdout and returns the
+// exit code of the run.
+func writeReport(report *internal.Report, err error) int {
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if err := internal.WriteReport(os.Stdout, viper.GetString(OUTPUT), report); err != nil {
+		logger.Fatal(err)
+	}
+	return report.ExitCode()
+}
+
+func applyPlan(ctx context.Context, planFile string) int {
+	if planFile == "" {
+		logger.Fatal("No plan file given")
+	}
+	file, err := os.Open(planFile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer file.Close()
+
+	plan, err := internal.ReadPlan(file)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if plan.Host != viper.GetString(GITLAB_HOST) {
+		logger.Fatalf("Plan was created for %s but GITLAB_HOST is %s", plan.Host, viper.GetString(GITLAB_HOST))
+	}
+	clinar.TotalRunners = plan.TotalRunners
+	return writeReport(clinar.ApplyPlan(ctx, plan))
+}
+
+func openAuditLog(ctx context.Context, path string) *internal.AuditLog {
+	user, _, err := clinar.Client.CurrentUser(ctx)
+	if err != nil {
+		logger.Fatalf("Error %s getting token owner for the audit log", err)
+	}
+	auditLog, err := internal.OpenAuditLog(path, viper.GetString(GITLAB_HOST), user.Username)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	return auditLog
+}
+
+func verifyAuditLog(path string) {
+	if path == "" {
+		logger.Fatal("No audit log given")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := internal.VerifyAuditLog(file); err != nil {
+		logger.Fatalf("Audit log %s was tampered with: %s", path, err)
+	}
+	logger.Infof("Audit log %s is valid", path)
+}
+
+func restoreInfo(backupFile string) {
+	if backupFile == "" {
+		logger.Fatal("No backup file given")
+	}
+	file, err := os.Open(backupFile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer file.Close()
+
+	backup, err := internal.ReadBackup(file)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if err := internal.WriteRestoreInfo(os.Stdout, backup); err != nil {
+		logger.Fatal(err)
 	}
 }
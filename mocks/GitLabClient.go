@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 
 	mock "github.com/stretchr/testify/mock"
@@ -21,20 +23,91 @@ func (_m *GitLabClient) EXPECT() *GitLabClient_Expecter {
 	return &GitLabClient_Expecter{mock: &_m.Mock}
 }
 
-// DeleteRegisteredRunnerByID provides a mock function with given fields: rid, options
-func (_m *GitLabClient) DeleteRegisteredRunnerByID(rid int, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+// CurrentUser provides a mock function with given fields: ctx, options
+func (_m *GitLabClient) CurrentUser(ctx context.Context, options ...gitlab.RequestOptionFunc) (*gitlab.User, *gitlab.Response, error) {
 	_va := make([]interface{}, len(options))
 	for _i := range options {
 		_va[_i] = options[_i]
 	}
 	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gitlab.User
+	if rf, ok := ret.Get(0).(func(context.Context, ...gitlab.RequestOptionFunc) *gitlab.User); ok {
+		r0 = rf(ctx, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.User)
+		}
+	}
+
+	var r1 *gitlab.Response
+	if rf, ok := ret.Get(1).(func(context.Context, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, options...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, options...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabClient_CurrentUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CurrentUser'
+type GitLabClient_CurrentUser_Call struct {
+	*mock.Call
+}
+
+// CurrentUser is a helper method to define mock.On call
+//  - ctx context.Context
+//  - options ...gitlab.RequestOptionFunc
+func (_e *GitLabClient_Expecter) CurrentUser(ctx interface{}, options ...interface{}) *GitLabClient_CurrentUser_Call {
+	return &GitLabClient_CurrentUser_Call{Call: _e.mock.On("CurrentUser",
+		append([]interface{}{ctx}, options...)...)}
+}
+
+func (_c *GitLabClient_CurrentUser_Call) Run(run func(ctx context.Context, options ...gitlab.RequestOptionFunc)) *GitLabClient_CurrentUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitLabClient_CurrentUser_Call) Return(_a0 *gitlab.User, _a1 *gitlab.Response, _a2 error) *GitLabClient_CurrentUser_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+// DeleteRegisteredRunnerByID provides a mock function with given fields: ctx, rid, options
+func (_m *GitLabClient) DeleteRegisteredRunnerByID(ctx context.Context, rid int, options ...gitlab.RequestOptionFunc) (*gitlab.Response, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, rid)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
 	var r0 *gitlab.Response
-	if rf, ok := ret.Get(0).(func(int, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
-		r0 = rf(rid, options...)
+	if rf, ok := ret.Get(0).(func(context.Context, int, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r0 = rf(ctx, rid, options...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*gitlab.Response)
@@ -42,8 +115,8 @@ func (_m *GitLabClient) DeleteRegisteredRunnerByID(rid int, options ...gitlab.Re
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int, ...gitlab.RequestOptionFunc) error); ok {
-		r1 = rf(rid, options...)
+	if rf, ok := ret.Get(1).(func(context.Context, int, ...gitlab.RequestOptionFunc) error); ok {
+		r1 = rf(ctx, rid, options...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -57,22 +130,23 @@ type GitLabClient_DeleteRegisteredRunnerByID_Call struct {
 }
 
 // DeleteRegisteredRunnerByID is a helper method to define mock.On call
+//  - ctx context.Context
 //  - rid int
 //  - options ...gitlab.RequestOptionFunc
-func (_e *GitLabClient_Expecter) DeleteRegisteredRunnerByID(rid interface{}, options ...interface{}) *GitLabClient_DeleteRegisteredRunnerByID_Call {
+func (_e *GitLabClient_Expecter) DeleteRegisteredRunnerByID(ctx interface{}, rid interface{}, options ...interface{}) *GitLabClient_DeleteRegisteredRunnerByID_Call {
 	return &GitLabClient_DeleteRegisteredRunnerByID_Call{Call: _e.mock.On("DeleteRegisteredRunnerByID",
-		append([]interface{}{rid}, options...)...)}
+		append([]interface{}{ctx, rid}, options...)...)}
 }
 
-func (_c *GitLabClient_DeleteRegisteredRunnerByID_Call) Run(run func(rid int, options ...gitlab.RequestOptionFunc)) *GitLabClient_DeleteRegisteredRunnerByID_Call {
+func (_c *GitLabClient_DeleteRegisteredRunnerByID_Call) Run(run func(ctx context.Context, rid int, options ...gitlab.RequestOptionFunc)) *GitLabClient_DeleteRegisteredRunnerByID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-1)
-		for i, a := range args[1:] {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
 			}
 		}
-		run(args[0].(int), variadicArgs...)
+		run(args[0].(context.Context), args[1].(int), variadicArgs...)
 	})
 	return _c
 }
@@ -82,20 +156,21 @@ func (_c *GitLabClient_DeleteRegisteredRunnerByID_Call) Return(_a0 *gitlab.Respo
 	return _c
 }
 
-// GetRunnerDetails provides a mock function with given fields: rid, options
-func (_m *GitLabClient) GetRunnerDetails(rid interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
+// GetRunnerDetails provides a mock function with given fields: ctx, rid, options
+func (_m *GitLabClient) GetRunnerDetails(ctx context.Context, rid interface{}, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
 	_va := make([]interface{}, len(options))
 	for _i := range options {
 		_va[_i] = options[_i]
 	}
 	var _ca []interface{}
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, rid)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
 	var r0 *gitlab.RunnerDetails
-	if rf, ok := ret.Get(0).(func(interface{}, ...gitlab.RequestOptionFunc) *gitlab.RunnerDetails); ok {
-		r0 = rf(rid, options...)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, ...gitlab.RequestOptionFunc) *gitlab.RunnerDetails); ok {
+		r0 = rf(ctx, rid, options...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*gitlab.RunnerDetails)
@@ -103,8 +178,8 @@ func (_m *GitLabClient) GetRunnerDetails(rid interface{}, options ...gitlab.Requ
 	}
 
 	var r1 *gitlab.Response
-	if rf, ok := ret.Get(1).(func(interface{}, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
-		r1 = rf(rid, options...)
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, rid, options...)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*gitlab.Response)
@@ -112,8 +187,8 @@ func (_m *GitLabClient) GetRunnerDetails(rid interface{}, options ...gitlab.Requ
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(interface{}, ...gitlab.RequestOptionFunc) error); ok {
-		r2 = rf(rid, options...)
+	if rf, ok := ret.Get(2).(func(context.Context, interface{}, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, rid, options...)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -127,22 +202,23 @@ type GitLabClient_GetRunnerDetails_Call struct {
 }
 
 // GetRunnerDetails is a helper method to define mock.On call
+//  - ctx context.Context
 //  - rid interface{}
 //  - options ...gitlab.RequestOptionFunc
-func (_e *GitLabClient_Expecter) GetRunnerDetails(rid interface{}, options ...interface{}) *GitLabClient_GetRunnerDetails_Call {
+func (_e *GitLabClient_Expecter) GetRunnerDetails(ctx interface{}, rid interface{}, options ...interface{}) *GitLabClient_GetRunnerDetails_Call {
 	return &GitLabClient_GetRunnerDetails_Call{Call: _e.mock.On("GetRunnerDetails",
-		append([]interface{}{rid}, options...)...)}
+		append([]interface{}{ctx, rid}, options...)...)}
 }
 
-func (_c *GitLabClient_GetRunnerDetails_Call) Run(run func(rid interface{}, options ...gitlab.RequestOptionFunc)) *GitLabClient_GetRunnerDetails_Call {
+func (_c *GitLabClient_GetRunnerDetails_Call) Run(run func(ctx context.Context, rid interface{}, options ...gitlab.RequestOptionFunc)) *GitLabClient_GetRunnerDetails_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-1)
-		for i, a := range args[1:] {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
 			}
 		}
-		run(args[0].(interface{}), variadicArgs...)
+		run(args[0].(context.Context), args[1].(interface{}), variadicArgs...)
 	})
 	return _c
 }
@@ -152,20 +228,168 @@ func (_c *GitLabClient_GetRunnerDetails_Call) Return(_a0 *gitlab.RunnerDetails,
 	return _c
 }
 
-// ListRunners provides a mock function with given fields: opt, options
-func (_m *GitLabClient) ListRunners(opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+// ListAllRunners provides a mock function with given fields: ctx, opt, options
+func (_m *GitLabClient) ListAllRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, opt)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []*gitlab.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) []*gitlab.Runner); ok {
+		r0 = rf(ctx, opt, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*gitlab.Runner)
+		}
+	}
+
+	var r1 *gitlab.Response
+	if rf, ok := ret.Get(1).(func(context.Context, *gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, opt, options...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, opt, options...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabClient_ListAllRunners_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAllRunners'
+type GitLabClient_ListAllRunners_Call struct {
+	*mock.Call
+}
+
+// ListAllRunners is a helper method to define mock.On call
+//  - ctx context.Context
+//  - opt *gitlab.ListRunnersOptions
+//  - options ...gitlab.RequestOptionFunc
+func (_e *GitLabClient_Expecter) ListAllRunners(ctx interface{}, opt interface{}, options ...interface{}) *GitLabClient_ListAllRunners_Call {
+	return &GitLabClient_ListAllRunners_Call{Call: _e.mock.On("ListAllRunners",
+		append([]interface{}{ctx, opt}, options...)...)}
+}
+
+func (_c *GitLabClient_ListAllRunners_Call) Run(run func(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_ListAllRunners_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*gitlab.ListRunnersOptions), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitLabClient_ListAllRunners_Call) Return(_a0 []*gitlab.Runner, _a1 *gitlab.Response, _a2 error) *GitLabClient_ListAllRunners_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+// ListDescendantGroups provides a mock function with given fields: ctx, gid, opt, options
+func (_m *GitLabClient) ListDescendantGroups(ctx context.Context, gid interface{}, opt *gitlab.ListDescendantGroupsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Group, *gitlab.Response, error) {
 	_va := make([]interface{}, len(options))
 	for _i := range options {
 		_va[_i] = options[_i]
 	}
 	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, gid)
+	_ca = append(_ca, opt)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []*gitlab.Group
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, *gitlab.ListDescendantGroupsOptions, ...gitlab.RequestOptionFunc) []*gitlab.Group); ok {
+		r0 = rf(ctx, gid, opt, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*gitlab.Group)
+		}
+	}
+
+	var r1 *gitlab.Response
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, *gitlab.ListDescendantGroupsOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, gid, opt, options...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, interface{}, *gitlab.ListDescendantGroupsOptions, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, gid, opt, options...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabClient_ListDescendantGroups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDescendantGroups'
+type GitLabClient_ListDescendantGroups_Call struct {
+	*mock.Call
+}
+
+// ListDescendantGroups is a helper method to define mock.On call
+//  - ctx context.Context
+//  - gid interface{}
+//  - opt *gitlab.ListDescendantGroupsOptions
+//  - options ...gitlab.RequestOptionFunc
+func (_e *GitLabClient_Expecter) ListDescendantGroups(ctx interface{}, gid interface{}, opt interface{}, options ...interface{}) *GitLabClient_ListDescendantGroups_Call {
+	return &GitLabClient_ListDescendantGroups_Call{Call: _e.mock.On("ListDescendantGroups",
+		append([]interface{}{ctx, gid, opt}, options...)...)}
+}
+
+func (_c *GitLabClient_ListDescendantGroups_Call) Run(run func(ctx context.Context, gid interface{}, opt *gitlab.ListDescendantGroupsOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_ListDescendantGroups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(interface{}), args[2].(*gitlab.ListDescendantGroupsOptions), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitLabClient_ListDescendantGroups_Call) Return(_a0 []*gitlab.Group, _a1 *gitlab.Response, _a2 error) *GitLabClient_ListDescendantGroups_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+// ListGroupsRunners provides a mock function with given fields: ctx, gid, opt, options
+func (_m *GitLabClient) ListGroupsRunners(ctx context.Context, gid interface{}, opt *gitlab.ListGroupsRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, gid)
 	_ca = append(_ca, opt)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
 	var r0 []*gitlab.Runner
-	if rf, ok := ret.Get(0).(func(*gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) []*gitlab.Runner); ok {
-		r0 = rf(opt, options...)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, *gitlab.ListGroupsRunnersOptions, ...gitlab.RequestOptionFunc) []*gitlab.Runner); ok {
+		r0 = rf(ctx, gid, opt, options...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*gitlab.Runner)
@@ -173,8 +397,8 @@ func (_m *GitLabClient) ListRunners(opt *gitlab.ListRunnersOptions, options ...g
 	}
 
 	var r1 *gitlab.Response
-	if rf, ok := ret.Get(1).(func(*gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
-		r1 = rf(opt, options...)
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, *gitlab.ListGroupsRunnersOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, gid, opt, options...)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*gitlab.Response)
@@ -182,8 +406,155 @@ func (_m *GitLabClient) ListRunners(opt *gitlab.ListRunnersOptions, options ...g
 	}
 
 	var r2 error
-	if rf, ok := ret.Get(2).(func(*gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) error); ok {
-		r2 = rf(opt, options...)
+	if rf, ok := ret.Get(2).(func(context.Context, interface{}, *gitlab.ListGroupsRunnersOptions, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, gid, opt, options...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabClient_ListGroupsRunners_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListGroupsRunners'
+type GitLabClient_ListGroupsRunners_Call struct {
+	*mock.Call
+}
+
+// ListGroupsRunners is a helper method to define mock.On call
+//  - ctx context.Context
+//  - gid interface{}
+//  - opt *gitlab.ListGroupsRunnersOptions
+//  - options ...gitlab.RequestOptionFunc
+func (_e *GitLabClient_Expecter) ListGroupsRunners(ctx interface{}, gid interface{}, opt interface{}, options ...interface{}) *GitLabClient_ListGroupsRunners_Call {
+	return &GitLabClient_ListGroupsRunners_Call{Call: _e.mock.On("ListGroupsRunners",
+		append([]interface{}{ctx, gid, opt}, options...)...)}
+}
+
+func (_c *GitLabClient_ListGroupsRunners_Call) Run(run func(ctx context.Context, gid interface{}, opt *gitlab.ListGroupsRunnersOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_ListGroupsRunners_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(interface{}), args[2].(*gitlab.ListGroupsRunnersOptions), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitLabClient_ListGroupsRunners_Call) Return(_a0 []*gitlab.Runner, _a1 *gitlab.Response, _a2 error) *GitLabClient_ListGroupsRunners_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+// ListProjectRunners provides a mock function with given fields: ctx, pid, opt, options
+func (_m *GitLabClient) ListProjectRunners(ctx context.Context, pid interface{}, opt *gitlab.ListProjectRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, pid)
+	_ca = append(_ca, opt)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []*gitlab.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, *gitlab.ListProjectRunnersOptions, ...gitlab.RequestOptionFunc) []*gitlab.Runner); ok {
+		r0 = rf(ctx, pid, opt, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*gitlab.Runner)
+		}
+	}
+
+	var r1 *gitlab.Response
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, *gitlab.ListProjectRunnersOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, pid, opt, options...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, interface{}, *gitlab.ListProjectRunnersOptions, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, pid, opt, options...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabClient_ListProjectRunners_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListProjectRunners'
+type GitLabClient_ListProjectRunners_Call struct {
+	*mock.Call
+}
+
+// ListProjectRunners is a helper method to define mock.On call
+//  - ctx context.Context
+//  - pid interface{}
+//  - opt *gitlab.ListProjectRunnersOptions
+//  - options ...gitlab.RequestOptionFunc
+func (_e *GitLabClient_Expecter) ListProjectRunners(ctx interface{}, pid interface{}, opt interface{}, options ...interface{}) *GitLabClient_ListProjectRunners_Call {
+	return &GitLabClient_ListProjectRunners_Call{Call: _e.mock.On("ListProjectRunners",
+		append([]interface{}{ctx, pid, opt}, options...)...)}
+}
+
+func (_c *GitLabClient_ListProjectRunners_Call) Run(run func(ctx context.Context, pid interface{}, opt *gitlab.ListProjectRunnersOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_ListProjectRunners_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(interface{}), args[2].(*gitlab.ListProjectRunnersOptions), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitLabClient_ListProjectRunners_Call) Return(_a0 []*gitlab.Runner, _a1 *gitlab.Response, _a2 error) *GitLabClient_ListProjectRunners_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+// ListRunners provides a mock function with given fields: ctx, opt, options
+func (_m *GitLabClient) ListRunners(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Runner, *gitlab.Response, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, opt)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 []*gitlab.Runner
+	if rf, ok := ret.Get(0).(func(context.Context, *gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) []*gitlab.Runner); ok {
+		r0 = rf(ctx, opt, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*gitlab.Runner)
+		}
+	}
+
+	var r1 *gitlab.Response
+	if rf, ok := ret.Get(1).(func(context.Context, *gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, opt, options...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *gitlab.ListRunnersOptions, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, opt, options...)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -197,22 +568,23 @@ type GitLabClient_ListRunners_Call struct {
 }
 
 // ListRunners is a helper method to define mock.On call
+//  - ctx context.Context
 //  - opt *gitlab.ListRunnersOptions
 //  - options ...gitlab.RequestOptionFunc
-func (_e *GitLabClient_Expecter) ListRunners(opt interface{}, options ...interface{}) *GitLabClient_ListRunners_Call {
+func (_e *GitLabClient_Expecter) ListRunners(ctx interface{}, opt interface{}, options ...interface{}) *GitLabClient_ListRunners_Call {
 	return &GitLabClient_ListRunners_Call{Call: _e.mock.On("ListRunners",
-		append([]interface{}{opt}, options...)...)}
+		append([]interface{}{ctx, opt}, options...)...)}
 }
 
-func (_c *GitLabClient_ListRunners_Call) Run(run func(opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_ListRunners_Call {
+func (_c *GitLabClient_ListRunners_Call) Run(run func(ctx context.Context, opt *gitlab.ListRunnersOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_ListRunners_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-1)
-		for i, a := range args[1:] {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-2)
+		for i, a := range args[2:] {
 			if a != nil {
 				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
 			}
 		}
-		run(args[0].(*gitlab.ListRunnersOptions), variadicArgs...)
+		run(args[0].(context.Context), args[1].(*gitlab.ListRunnersOptions), variadicArgs...)
 	})
 	return _c
 }
@@ -221,3 +593,77 @@ func (_c *GitLabClient_ListRunners_Call) Return(_a0 []*gitlab.Runner, _a1 *gitla
 	_c.Call.Return(_a0, _a1, _a2)
 	return _c
 }
+
+// UpdateRunnerDetails provides a mock function with given fields: ctx, rid, opt, options
+func (_m *GitLabClient) UpdateRunnerDetails(ctx context.Context, rid interface{}, opt *gitlab.UpdateRunnerDetailsOptions, options ...gitlab.RequestOptionFunc) (*gitlab.RunnerDetails, *gitlab.Response, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, rid)
+	_ca = append(_ca, opt)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *gitlab.RunnerDetails
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, *gitlab.UpdateRunnerDetailsOptions, ...gitlab.RequestOptionFunc) *gitlab.RunnerDetails); ok {
+		r0 = rf(ctx, rid, opt, options...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.RunnerDetails)
+		}
+	}
+
+	var r1 *gitlab.Response
+	if rf, ok := ret.Get(1).(func(context.Context, interface{}, *gitlab.UpdateRunnerDetailsOptions, ...gitlab.RequestOptionFunc) *gitlab.Response); ok {
+		r1 = rf(ctx, rid, opt, options...)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, interface{}, *gitlab.UpdateRunnerDetailsOptions, ...gitlab.RequestOptionFunc) error); ok {
+		r2 = rf(ctx, rid, opt, options...)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabClient_UpdateRunnerDetails_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateRunnerDetails'
+type GitLabClient_UpdateRunnerDetails_Call struct {
+	*mock.Call
+}
+
+// UpdateRunnerDetails is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rid interface{}
+//  - opt *gitlab.UpdateRunnerDetailsOptions
+//  - options ...gitlab.RequestOptionFunc
+func (_e *GitLabClient_Expecter) UpdateRunnerDetails(ctx interface{}, rid interface{}, opt interface{}, options ...interface{}) *GitLabClient_UpdateRunnerDetails_Call {
+	return &GitLabClient_UpdateRunnerDetails_Call{Call: _e.mock.On("UpdateRunnerDetails",
+		append([]interface{}{ctx, rid, opt}, options...)...)}
+}
+
+func (_c *GitLabClient_UpdateRunnerDetails_Call) Run(run func(ctx context.Context, rid interface{}, opt *gitlab.UpdateRunnerDetailsOptions, options ...gitlab.RequestOptionFunc)) *GitLabClient_UpdateRunnerDetails_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gitlab.RequestOptionFunc, len(args)-3)
+		for i, a := range args[3:] {
+			if a != nil {
+				variadicArgs[i] = a.(gitlab.RequestOptionFunc)
+			}
+		}
+		run(args[0].(context.Context), args[1].(interface{}), args[2].(*gitlab.UpdateRunnerDetailsOptions), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *GitLabClient_UpdateRunnerDetails_Call) Return(_a0 *gitlab.RunnerDetails, _a1 *gitlab.Response, _a2 error) *GitLabClient_UpdateRunnerDetails_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
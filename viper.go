@@ -6,11 +6,14 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/getsops/sops/v3/decrypt"
 	logger "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/steffakasid/clinar/internal"
 )
 
 const (
@@ -19,12 +22,50 @@ const (
 )
 
 const (
-	GITLAB_HOST  = "GITLAB_HOST"
-	GTILAB_TOKEN = "GITLAB_TOKEN"
-	APPROVE      = "approve"
-	EXCLUDE      = "exclude"
-	INCLUDE      = "include"
-	LOG_LEVEL    = "LOG_LEVEL"
+	GITLAB_HOST          = "GITLAB_HOST"
+	GTILAB_TOKEN         = "GITLAB_TOKEN"
+	APPROVE              = "approve"
+	TIMEOUT              = "timeout"
+	OUT                  = "out"
+	OUTPUT               = "output"
+	TEMPLATE             = "template"
+	TEMPLATE_FILE        = "template-file"
+	TEMPLATE_SCOPE       = "template-scope"
+	INTERACTIVE          = "interactive"
+	MAX_DELETE           = "max-delete"
+	MAX_DELETE_PERCENT   = "max-delete-percent"
+	FORCE                = "force"
+	AUDIT_LOG            = "audit-log"
+	BACKUP_DIR           = "backup-dir"
+	CONCURRENCY          = "concurrency"
+	DELETE_PARALLELISM   = "delete-parallelism"
+	DELETE_RATE          = "delete-rate"
+	BATCH_SIZE           = "batch-size"
+	BATCH_PAUSE          = "batch-pause"
+	RETRY_MAX_ATTEMPTS   = "retry-max-attempts"
+	RETRY_TIMEOUT        = "retry-timeout"
+	QUARANTINE           = "quarantine"
+	GRACE_PERIOD         = "grace-period"
+	EXCLUDE              = "exclude"
+	INCLUDE              = "include"
+	STATUS               = "status"
+	TYPE                 = "type"
+	ALL                  = "all"
+	GROUP                = "group"
+	PROJECT              = "project"
+	INCLUDE_SUBGROUPS    = "include-subgroups"
+	INCLUDE_TAG          = "include-tag"
+	EXCLUDE_TAG          = "exclude-tag"
+	TAG_MATCH            = "tag-match"
+	PROTECT_TAG          = "protect-tag"
+	MIN_VERSION          = "min-version"
+	MAX_VERSION          = "max-version"
+	PLATFORM             = "platform"
+	ARCHITECTURE         = "architecture"
+	OLDER_THAN           = "older-than"
+	SKIP_NEVER_CONTACTED = "skip-never-contacted"
+	WHERE                = "where"
+	LOG_LEVEL            = "LOG_LEVEL"
 )
 
 func InitConfig() {
@@ -67,6 +108,21 @@ func InitConfig() {
 	}
 
 	clinar.ExcludeFilter = viper.GetStringSlice("exclude")
+	if err := internal.ValidateFilters(clinar.ExcludeFilter); err != nil {
+		logger.Fatal(err)
+	}
+	clinar.States = viper.GetStringSlice(STATUS)
+	clinar.AllRunners = viper.GetBool(ALL)
+	clinar.Concurrency = viper.GetInt(CONCURRENCY)
+
+	types, err := internal.ParseRunnerTypes(viper.GetStringSlice(TYPE))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	clinar.Types = types
+	clinar.Groups = viper.GetStringSlice(GROUP)
+	clinar.Projects = viper.GetStringSlice(PROJECT)
+	clinar.IncludeSubgroups = viper.GetBool(INCLUDE_SUBGROUPS)
 
 	if viper.GetString(INCLUDE) != "" {
 		rex, err := regexp.Compile(viper.GetString(INCLUDE))
@@ -75,6 +131,95 @@ func InitConfig() {
 		}
 		clinar.IncludePattern = rex
 	}
+
+	clinar.IncludeTags = viper.GetStringSlice(INCLUDE_TAG)
+	clinar.ExcludeTags = viper.GetStringSlice(EXCLUDE_TAG)
+	clinar.ProtectTags = viper.GetStringSlice(PROTECT_TAG)
+	clinar.TagMatch = viper.GetString(TAG_MATCH)
+	if clinar.TagMatch != internal.TagMatchAny && clinar.TagMatch != internal.TagMatchAll {
+		logger.Fatalf("%s must be either %s or %s", TAG_MATCH, internal.TagMatchAny, internal.TagMatchAll)
+	}
+
+	if viper.GetString(MIN_VERSION) != "" {
+		minVersion, err := internal.ParseVersion(viper.GetString(MIN_VERSION))
+		if err != nil {
+			logger.Fatal(err)
+		}
+		clinar.MinVersion = minVersion
+	}
+	if viper.GetString(MAX_VERSION) != "" {
+		maxVersion, err := internal.ParseVersion(viper.GetString(MAX_VERSION))
+		if err != nil {
+			logger.Fatal(err)
+		}
+		clinar.MaxVersion = maxVersion
+	}
+	clinar.Platforms = viper.GetStringSlice(PLATFORM)
+	clinar.Architectures = viper.GetStringSlice(ARCHITECTURE)
+
+	if viper.GetString(OLDER_THAN) != "" {
+		olderThan, err := internal.ParseDuration(viper.GetString(OLDER_THAN))
+		if err != nil {
+			logger.Fatal(err)
+		}
+		clinar.OlderThan = olderThan
+	}
+	clinar.SkipNeverContacted = viper.GetBool(SKIP_NEVER_CONTACTED)
+
+	gracePeriod, err := internal.ParseDuration(viper.GetString(GRACE_PERIOD))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	clinar.GracePeriod = gracePeriod
+
+	if !slices.Contains(internal.OutputFormats, viper.GetString(OUTPUT)) {
+		logger.Fatalf("Unknown output format %s, must be one of %s", viper.GetString(OUTPUT), strings.Join(internal.OutputFormats, ", "))
+	}
+	initTemplate()
+
+	clinar.MaxDelete = viper.GetInt(MAX_DELETE)
+	clinar.MaxDeletePercent = viper.GetFloat64(MAX_DELETE_PERCENT)
+	clinar.Force = viper.GetBool(FORCE)
+	clinar.DeleteParallelism = viper.GetInt(DELETE_PARALLELISM)
+	clinar.DeleteRate = viper.GetFloat64(DELETE_RATE)
+	clinar.BatchSize = viper.GetInt(BATCH_SIZE)
+	clinar.BatchPause = viper.GetDuration(BATCH_PAUSE)
+	clinar.BackupDir = viper.GetString(BACKUP_DIR)
+	clinar.Host = viper.GetString(GITLAB_HOST)
+
+	if viper.GetString(WHERE) != "" {
+		where, err := internal.CompileWhere(viper.GetString(WHERE))
+		if err != nil {
+			logger.Fatal(err)
+		}
+		clinar.Where = where
+	}
+}
+
+func initTemplate() {
+	text := viper.GetString(TEMPLATE)
+	if viper.GetString(TEMPLATE_FILE) != "" {
+		if text != "" {
+			logger.Fatalf("Only one of --%s and --%s can be given", TEMPLATE, TEMPLATE_FILE)
+		}
+		content, err := os.ReadFile(viper.GetString(TEMPLATE_FILE))
+		if err != nil {
+			logger.Fatal(err)
+		}
+		text = string(content)
+	}
+	scope := viper.GetString(TEMPLATE_SCOPE)
+	if scope != internal.TemplateScopeRunner && scope != internal.TemplateScopeSummary {
+		logger.Fatalf("%s must be either %s or %s", TEMPLATE_SCOPE, internal.TemplateScopeRunner, internal.TemplateScopeSummary)
+	}
+	if text == "" {
+		return
+	}
+	tmpl, err := internal.ParseTemplate(text)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	outputTemplate = tmpl
 }
 
 func getConfigFilename(homedir string) string {